@@ -1,17 +1,34 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/config"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jakottelaar/gobookreviewapp/internal/account"
+	"github.com/jakottelaar/gobookreviewapp/internal/auth"
 	"github.com/jakottelaar/gobookreviewapp/internal/book"
+	"github.com/jakottelaar/gobookreviewapp/internal/suggestion"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
 	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/contentfilter"
 	"github.com/jakottelaar/gobookreviewapp/pkg/database"
+	"github.com/jakottelaar/gobookreviewapp/pkg/mailer"
+	"github.com/jakottelaar/gobookreviewapp/pkg/oidc"
+	"github.com/jakottelaar/gobookreviewapp/pkg/ratelimit"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func SetupRoutes() *chi.Mux {
+// SetupRoutes returns the router of the API and the account service, whose
+// purge the caller runs for as long as the server is up.
+func SetupRoutes(cfg *config.Config) (*chi.Mux, account.AccountService, error) {
 	r := chi.NewRouter()
 
 	// Middleware
@@ -28,6 +45,83 @@ func SetupRoutes() *chi.Mux {
 	bookService := book.NewBookService(bookRepository)
 	bookHandler := book.NewBookHandler(bookService)
 
+	err := bookService.BuildSimilarityIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Setup content filter
+	filterAction, err := contentfilter.ParseAction(cfg.ContentFilter.DefaultAction)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contentFilter, err := contentfilter.LoadWordlistFilter(cfg.ContentFilter.Wordlist, filterAction)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Setup suggestion services
+	suggestionRepository := suggestion.NewSuggestionRepository(db)
+	suggestionService := suggestion.NewSuggestionService(suggestionRepository, bookService, contentFilter)
+	suggestionHandler := suggestion.NewSuggestionHandler(suggestionService)
+
+	// Setup user services
+	userRepository := user.NewUserRepository(db)
+	tokenRepository := user.NewTokenRepository(db)
+
+	mail, err := newMailer(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userService := user.NewUserService(userRepository, tokenRepository, mailer.NewAsyncMailer(mail), cfg.AppURL, contentFilter)
+	userHandler := user.NewUserHandler(userService)
+
+	// Setup auth services
+	if len(cfg.Auth.Secret) < 32 {
+		return nil, nil, errors.New("JWT_SECRET must be at least 32 characters long")
+	}
+
+	// Single sign-on is optional
+	var identityProvider auth.IdentityProvider
+
+	if cfg.OIDC.Issuer != "" {
+		provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		identityProvider = provider
+	}
+
+	refreshTokenRepository := auth.NewRefreshTokenRepository(db)
+	sessionRepository := auth.NewSessionRepository(db)
+	apiKeyRepository := auth.NewAPIKeyRepository(db)
+	authService := auth.NewAuthService(refreshTokenRepository, sessionRepository, apiKeyRepository, userService, identityProvider, auth.TokenConfig{
+		Secret:          []byte(cfg.Auth.Secret),
+		AccessTokenTTL:  cfg.Auth.AccessTokenTTL,
+		RefreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+	})
+	authHandler := auth.NewAuthHandler(authService, userService)
+
+	// Setup account services
+	exportRepository := account.NewExportRepository(db)
+	deletionRepository := account.NewDeletionRepository(db)
+	accountService := account.NewAccountService(exportRepository, deletionRepository, userService, authService, suggestionService, cfg.Account.DeletionGracePeriod)
+	accountHandler := account.NewAccountHandler(accountService)
+
+	// Password reset requests send email, so they are limited per client
+	passwordResetLimiter := ratelimit.New(5, time.Hour)
+
+	// Codes have a million values, so guesses are limited per client
+	twoFactorLimiter := ratelimit.New(10, time.Minute)
+
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		err := common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Health Check OK"}, nil)
@@ -39,13 +133,147 @@ func SetupRoutes() *chi.Mux {
 
 	// API routes
 	r.Route("/v1/api", func(r chi.Router) {
+		r.Use(auth.Authenticate(authService))
+
 		r.Route("/books", func(r chi.Router) {
-			r.Post("/", bookHandler.CreateBook)
-			r.Get("/{id}", bookHandler.GetBookById)
-			r.Put("/{id}", bookHandler.UpdateBook)
-			r.Delete("/{id}", bookHandler.DeleteBook)
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(auth.ScopeBooksRead))
+
+				r.Get("/{id}", bookHandler.GetBookById)
+				r.Get("/{id}/similar", bookHandler.SimilarBooks)
+				r.Get("/{id}/translations", bookHandler.ListTranslations)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(auth.ScopeBooksWrite))
+
+				r.With(auth.RequirePermission(auth.PermissionSuggestionsSubmit)).Post("/{id}/suggestions", suggestionHandler.SubmitSuggestion)
+
+				r.Group(func(r chi.Router) {
+					r.Use(auth.RequirePermission(auth.PermissionBooksWrite))
+
+					r.Post("/", bookHandler.CreateBook)
+					r.Put("/{id}", bookHandler.UpdateBook)
+					r.Put("/{id}/translations/{language}", bookHandler.PutTranslation)
+					r.Delete("/{id}/translations/{language}", bookHandler.DeleteTranslation)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(auth.RequirePermission(auth.PermissionBooksDelete))
+
+					r.Delete("/{id}", bookHandler.DeleteBook)
+					r.Post("/{id}/merge", bookHandler.MergeBook)
+				})
+			})
+		})
+
+		// The moderation queue shows who submitted what, so only reviewers
+		// can read it. Submitters see their own suggestions at /me/suggestions.
+		r.Route("/suggestions", func(r chi.Router) {
+			r.Use(auth.RequireScope(auth.ScopeBooksRead))
+			r.Use(auth.RequirePermission(auth.PermissionSuggestionsReview))
+
+			r.Get("/", suggestionHandler.ListSuggestions)
+			r.Get("/{id}", suggestionHandler.GetSuggestionById)
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(auth.ScopeBooksWrite))
+
+				r.Post("/{id}/approve", suggestionHandler.ApproveSuggestion)
+				r.Post("/{id}/reject", suggestionHandler.RejectSuggestion)
+			})
+		})
+
+		r.With(auth.RequireUser, auth.RequireScope(auth.ScopeBooksRead)).Get("/me/suggestions", suggestionHandler.ListOwnSuggestions)
+
+		// Batches can delete books, so they need the delete permission.
+		r.With(auth.RequireScope(auth.ScopeBooksWrite), auth.RequirePermission(auth.PermissionBooksDelete)).Post("/batch", bookHandler.Batch)
+
+		r.Route("/users", func(r chi.Router) {
+			r.Post("/", userHandler.Register)
+			r.Get("/{id}", userHandler.GetProfile)
+
+			r.With(auth.RequireScope(auth.ScopeAdmin), auth.RequirePermission(auth.PermissionUsersManage)).Put("/{id}/role", userHandler.SetRole)
+		})
+
+		r.Route("/auth", func(r chi.Router) {
+			r.Post("/login", authHandler.Login)
+			r.With(twoFactorLimiter.Middleware).Post("/login/two-factor", authHandler.LoginTwoFactor)
+			r.Post("/refresh", authHandler.Refresh)
+			r.Post("/logout", authHandler.Logout)
+			r.Get("/oidc/login", authHandler.OIDCLogin)
+			r.Get("/oidc/callback", authHandler.OIDCCallback)
+			r.With(auth.RequireUser, auth.RequireScope(auth.ScopeAdmin)).Post("/oidc/link", authHandler.OIDCLink)
+			r.Post("/verify-email", authHandler.VerifyEmail)
+			r.With(auth.RequireUser).Post("/verify-email/resend", authHandler.ResendVerificationEmail)
+			r.With(passwordResetLimiter.Middleware).Post("/password-reset", authHandler.RequestPasswordReset)
+			r.Post("/password-reset/confirm", authHandler.ResetPassword)
+		})
+
+		r.Route("/me/profile", func(r chi.Router) {
+			r.Use(auth.RequireUser)
+			r.Use(auth.RequireScope(auth.ScopeAdmin))
+
+			r.Get("/", userHandler.GetOwnProfile)
+			r.Put("/", userHandler.UpdateProfile)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireUser)
+			r.Use(auth.RequireScope(auth.ScopeAdmin))
+
+			r.Post("/me/export", accountHandler.RequestExport)
+			r.Get("/me/exports/{id}", accountHandler.GetExport)
+			r.Get("/me/exports/{id}/download", accountHandler.DownloadExport)
+			r.Post("/me/deletion", accountHandler.ScheduleDeletion)
+			r.Delete("/me/deletion", accountHandler.CancelDeletion)
+		})
+
+		r.Route("/me/sessions", func(r chi.Router) {
+			r.Use(auth.RequireUser)
+			r.Use(auth.RequireScope(auth.ScopeAdmin))
+
+			r.Get("/", authHandler.ListSessions)
+			r.Delete("/{id}", authHandler.RevokeSession)
+		})
+
+		r.Route("/me/two-factor", func(r chi.Router) {
+			r.Use(auth.RequireUser)
+			r.Use(auth.RequireScope(auth.ScopeAdmin))
+
+			r.Post("/", authHandler.BeginTwoFactor)
+			r.Post("/confirm", authHandler.ConfirmTwoFactor)
+			r.Post("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+			r.Delete("/", authHandler.DisableTwoFactor)
+		})
+
+		r.Route("/api-keys", func(r chi.Router) {
+			r.Use(auth.RequireUser)
+			r.Use(auth.RequireScope(auth.ScopeAdmin))
+
+			r.Post("/", authHandler.CreateAPIKey)
+			r.Get("/", authHandler.ListAPIKeys)
+			r.Delete("/{id}", authHandler.RevokeAPIKey)
 		})
 	})
 
-	return r
+	return r, accountService, nil
+}
+
+// newMailer returns the mailer configured with MAILER.
+func newMailer(cfg *config.Config) (mailer.Mailer, error) {
+	switch cfg.Mail.Mailer {
+	case "stdout":
+		return mailer.NewWriterMailer(os.Stdout, cfg.Mail.From), nil
+	case "file":
+		return mailer.NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+	case "smtp":
+		if cfg.Mail.SMTP.Host == "" {
+			return nil, errors.New("SMTP_HOST must be set when MAILER is smtp")
+		}
+
+		return mailer.NewSMTPMailer(cfg.Mail.SMTP.Host, cfg.Mail.SMTP.Port, cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, cfg.Mail.From), nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q, expected stdout, file or smtp", cfg.Mail.Mailer)
+	}
 }
@@ -1,43 +0,0 @@
-package api
-
-import (
-	"errors"
-	"fmt"
-	"net/http"
-	"time"
-
-	"github.com/jakottelaar/gobookreviewapp/config"
-	"github.com/rs/cors"
-)
-
-func Serve(cfg *config.Config) error {
-
-	routes := SetupRoutes()
-
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      routes,
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-	}
-
-	corsMiddleware := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	})
-
-	routes.Use(corsMiddleware.Handler)
-
-	err := srv.ListenAndServe()
-
-	if !errors.Is(err, http.ErrServerClosed) {
-		return err
-	}
-
-	return nil
-}
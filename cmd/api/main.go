@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/jakottelaar/gobookreviewapp/api"
 	"github.com/jakottelaar/gobookreviewapp/config"
 	_ "github.com/jakottelaar/gobookreviewapp/docs"
+	"github.com/jakottelaar/gobookreviewapp/internal/account"
 	"github.com/jakottelaar/gobookreviewapp/pkg/database"
 )
 
@@ -20,6 +26,11 @@ import (
 // @schemes		http
 // @produces		json
 // @consumes		json
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Access token, prefixed with "Bearer "
 func main() {
 	cfg, err := config.Load()
 
@@ -37,12 +48,46 @@ func main() {
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	router := api.SetupRoutes()
+	// The server and the purge stop on an interrupt or termination signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	logger.Info("Starting server", "port", cfg.Port, "Environment", cfg.Environment)
-	err = http.ListenAndServe(":8080", router)
+	router, accountService, err := api.SetupRoutes(cfg)
 	if err != nil {
+		log.Fatalf("Could not setup routes: %v", err)
+	}
+
+	purged := make(chan struct{})
+	go func() {
+		defer close(purged)
+		account.RunPurge(ctx, accountService)
+	}()
+
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := srv.Shutdown(shutdownCtx)
+		if err != nil {
+			logger.Error("Could not shut down server", "error", err)
+		}
+	}()
+
+	logger.Info("Starting server", "port", cfg.Port, "Environment", cfg.Environment)
+	err = srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
 		logger.Error("Server failed to start", "error", err)
 		os.Exit(1)
 	}
+
+	// A purge that is running finishes before the database is closed
+	<-purged
+	logger.Info("Server stopped")
 }
@@ -18,6 +18,40 @@ type Config struct {
 		MaxIdleConns int
 		MaxIdleTime  time.Duration
 	}
+	ContentFilter struct {
+		Wordlist      string
+		DefaultAction string
+	}
+	Auth struct {
+		Secret          string
+		AccessTokenTTL  time.Duration
+		RefreshTokenTTL time.Duration
+	}
+	OIDC struct {
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+		RedirectURL  string
+	}
+	Account struct {
+		// DeletionGracePeriod is how long a user can cancel the deletion of
+		// their account.
+		DeletionGracePeriod time.Duration
+	}
+	// AppURL is the base URL of the links in emails.
+	AppURL string
+	Mail   struct {
+		// Mailer is where emails go: stdout, file or smtp.
+		Mailer string
+		From   string
+		Dir    string
+		SMTP   struct {
+			Host     string
+			Port     int
+			Username string
+			Password string
+		}
+	}
 }
 
 func Load() (*Config, error) {
@@ -38,6 +72,24 @@ func Load() (*Config, error) {
 	cfg.Database.MaxIdleConns = getEnvAsInt("DATABASE_MAX_IDLE_CONNS", 25)
 	cfg.Database.MaxOpenConns = getEnvAsInt("DATABASE_MAX_OPEN_CONNS", 25)
 	cfg.Database.MaxIdleTime = time.Duration(getEnvAsInt("DATABASE_MAX_IDLE_TIME", 5000))
+	cfg.ContentFilter.Wordlist = getEnv("CONTENT_FILTER_WORDLIST", "")
+	cfg.ContentFilter.DefaultAction = getEnv("CONTENT_FILTER_ACTION", "reject")
+	cfg.Auth.Secret = getEnv("JWT_SECRET", "")
+	cfg.Auth.AccessTokenTTL = getEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	cfg.Auth.RefreshTokenTTL = getEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	cfg.OIDC.Issuer = getEnv("OIDC_ISSUER", "")
+	cfg.OIDC.ClientID = getEnv("OIDC_CLIENT_ID", "")
+	cfg.OIDC.ClientSecret = getEnv("OIDC_CLIENT_SECRET", "")
+	cfg.OIDC.RedirectURL = getEnv("OIDC_REDIRECT_URL", "")
+	cfg.Account.DeletionGracePeriod = getEnvAsDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	cfg.AppURL = getEnv("APP_URL", "http://localhost:8080")
+	cfg.Mail.Mailer = getEnv("MAILER", "stdout")
+	cfg.Mail.From = getEnv("MAIL_FROM", "Book Reviews <no-reply@localhost>")
+	cfg.Mail.Dir = getEnv("MAILER_DIR", "mail")
+	cfg.Mail.SMTP.Host = getEnv("SMTP_HOST", "")
+	cfg.Mail.SMTP.Port = getEnvAsInt("SMTP_PORT", 587)
+	cfg.Mail.SMTP.Username = getEnv("SMTP_USERNAME", "")
+	cfg.Mail.SMTP.Password = getEnv("SMTP_PASSWORD", "")
 
 	return &cfg, nil
 }
@@ -57,3 +109,12 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,66 @@
+// Package account handles the requests of users about their own data: an
+// export of everything stored about them and the deletion of their account.
+package account
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExportStatusPending = "pending"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+)
+
+const (
+	// exportBuildTimeout is how long an export may stay pending. Exports
+	// that were interrupted, for example by a restart, are purged after it.
+	exportBuildTimeout = time.Hour
+	// exportTTL is how long a finished export can be downloaded.
+	exportTTL = 7 * 24 * time.Hour
+	// purgeInterval is how often expired exports and accounts past their
+	// grace period are purged.
+	purgeInterval = time.Hour
+)
+
+var (
+	ErrExportInProgress = errors.New("an export of your data is already being prepared")
+	ErrExportNotReady   = errors.New("the export is not ready to download")
+)
+
+// Export is an archive of all data of a user. It is built in the background
+// after it has been requested.
+type Export struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      string
+	Archive     []byte
+	CompletedAt *time.Time
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+type ExportResponse struct {
+	ID          string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Status      string     `json:"status" example:"ready"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" example:"2024-01-01T00:01:00Z"`
+	ExpiresAt   time.Time  `json:"expires_at" example:"2024-01-08T00:01:00Z"`
+	CreatedAt   time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+func newExportResponse(export *Export) ExportResponse {
+	return ExportResponse{
+		ID:          export.ID.String(),
+		Status:      export.Status,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+		CreatedAt:   export.CreatedAt,
+	}
+}
+
+type DeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at" example:"2024-02-01T00:00:00Z"`
+}
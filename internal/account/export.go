@@ -0,0 +1,288 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/internal/suggestion"
+)
+
+// exportReadme describes the files of an export.
+const exportReadme = `This archive contains all data the Book Review API stores about you.
+
+account.json      your account and public profile
+identities.json   the single sign-on accounts linked to your account
+sessions.json     the devices you are logged in on
+api_keys.json     your API keys, without the keys themselves
+tokens.json       the email verification and password reset links sent to
+                  you, without the links themselves
+suggestions.json  the edit suggestions you submitted
+reviews.json      the edit suggestions you reviewed, with your comments
+
+Passwords, two-factor secrets, recovery codes and the links in emails are
+stored only in a form that cannot be read back, so they are not included.
+`
+
+// The types below are the format of the files in an export. They are kept
+// apart from the API responses so the export does not change with the API.
+
+type exportedAccount struct {
+	ID                  string          `json:"id"`
+	Email               string          `json:"email"`
+	Name                string          `json:"name"`
+	Role                string          `json:"role"`
+	EmailVerifiedAt     *time.Time      `json:"email_verified_at"`
+	TwoFactorEnabledAt  *time.Time      `json:"two_factor_enabled_at"`
+	DeletionScheduledAt *time.Time      `json:"deletion_scheduled_at"`
+	Profile             exportedProfile `json:"profile"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
+type exportedProfile struct {
+	DisplayName  string `json:"display_name"`
+	Bio          string `json:"bio"`
+	AvatarURL    string `json:"avatar_url"`
+	HideActivity bool   `json:"hide_activity"`
+}
+
+type exportedIdentity struct {
+	ID        string    `json:"id"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedSession struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportedAPIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type exportedToken struct {
+	ID        string     `json:"id"`
+	Purpose   string     `json:"purpose"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type exportedSuggestion struct {
+	ID            string             `json:"id"`
+	BookID        string             `json:"book_id"`
+	Changes       suggestion.Changes `json:"changes"`
+	Comment       string             `json:"comment"`
+	Status        string             `json:"status"`
+	ReviewComment string             `json:"review_comment"`
+	ReviewedAt    *time.Time         `json:"reviewed_at"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+type exportedReview struct {
+	SuggestionID  string             `json:"suggestion_id"`
+	BookID        string             `json:"book_id"`
+	Changes       suggestion.Changes `json:"changes"`
+	Status        string             `json:"status"`
+	ReviewComment string             `json:"review_comment"`
+	ReviewedAt    *time.Time         `json:"reviewed_at"`
+}
+
+// buildArchive collects the data of a user into a ZIP archive of JSON files.
+func (s *accountService) buildArchive(userID string) ([]byte, error) {
+	u, err := s.users.GetUserById(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.users.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := s.users.ListIdentities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.auth.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.auth.ListAPIKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.users.ListTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions, err := s.suggestions.ListBySubmitter(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := s.suggestions.ListByReviewer(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := exportedAccount{
+		ID:                  u.ID.String(),
+		Email:               u.Email,
+		Name:                u.Name,
+		Role:                u.Role,
+		EmailVerifiedAt:     u.EmailVerifiedAt,
+		TwoFactorEnabledAt:  u.TwoFactorEnabledAt,
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		Profile: exportedProfile{
+			DisplayName:  profile.DisplayName,
+			Bio:          profile.Bio,
+			AvatarURL:    profile.AvatarURL,
+			HideActivity: profile.HideActivity,
+		},
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+
+	exportedIdentities := make([]exportedIdentity, len(identities))
+	for i, identity := range identities {
+		exportedIdentities[i] = exportedIdentity{
+			ID:        identity.ID.String(),
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		}
+	}
+
+	exportedSessions := make([]exportedSession, len(sessions))
+	for i, session := range sessions {
+		exportedSessions[i] = exportedSession{
+			ID:         session.ID.String(),
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			LastUsedAt: session.LastUsedAt,
+			CreatedAt:  session.CreatedAt,
+		}
+	}
+
+	exportedKeys := make([]exportedAPIKey, len(keys))
+	for i, key := range keys {
+		exportedKeys[i] = exportedAPIKey{
+			ID:         key.ID.String(),
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			RevokedAt:  key.RevokedAt,
+			CreatedAt:  key.CreatedAt,
+		}
+	}
+
+	exportedTokens := make([]exportedToken, len(tokens))
+	for i, token := range tokens {
+		exportedTokens[i] = exportedToken{
+			ID:        token.ID.String(),
+			Purpose:   token.Purpose,
+			ExpiresAt: token.ExpiresAt,
+			UsedAt:    token.UsedAt,
+			CreatedAt: token.CreatedAt,
+		}
+	}
+
+	exportedSuggestions := make([]exportedSuggestion, len(suggestions))
+	for i, submitted := range suggestions {
+		exportedSuggestions[i] = exportedSuggestion{
+			ID:            submitted.ID.String(),
+			BookID:        submitted.BookID.String(),
+			Changes:       submitted.Changes,
+			Comment:       submitted.Comment,
+			Status:        submitted.Status,
+			ReviewComment: submitted.ReviewComment,
+			ReviewedAt:    submitted.ReviewedAt,
+			CreatedAt:     submitted.CreatedAt,
+		}
+	}
+
+	exportedReviews := make([]exportedReview, len(reviews))
+	for i, reviewed := range reviews {
+		exportedReviews[i] = exportedReview{
+			SuggestionID:  reviewed.ID.String(),
+			BookID:        reviewed.BookID.String(),
+			Changes:       reviewed.Changes,
+			Status:        reviewed.Status,
+			ReviewComment: reviewed.ReviewComment,
+			ReviewedAt:    reviewed.ReviewedAt,
+		}
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"account.json", account},
+		{"identities.json", exportedIdentities},
+		{"sessions.json", exportedSessions},
+		{"api_keys.json", exportedKeys},
+		{"tokens.json", exportedTokens},
+		{"suggestions.json", exportedSuggestions},
+		{"reviews.json", exportedReviews},
+	}
+
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.data, "", "\t")
+		if err != nil {
+			return nil, err
+		}
+
+		err = writeFile(archive, file.name, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = writeFile(archive, "README.txt", []byte(exportReadme))
+	if err != nil {
+		return nil, err
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeFile(archive *zip.Writer, name string, data []byte) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
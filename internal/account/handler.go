@@ -0,0 +1,191 @@
+package account
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jakottelaar/gobookreviewapp/internal/auth"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+)
+
+type AccountHandler struct {
+	service AccountService
+}
+
+func NewAccountHandler(service AccountService) *AccountHandler {
+	return &AccountHandler{
+		service: service,
+	}
+}
+
+// RequestExport godoc
+// @Summary Export your data
+// @Description Start building a ZIP archive of all data stored about the current user. Follow the Location header to see when it is ready to download.
+// @Tags account
+// @Produce json
+// @Success 202 {object} ExportResponse
+// @Security BearerAuth
+// @Router /me/export [post]
+func (h *AccountHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.ContextGetUser(r)
+
+	export, err := h.service.RequestExport(u.ID.String())
+
+	if err != nil {
+		switch err {
+		case ErrExportInProgress:
+			common.ConflictResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", "/v1/api/me/exports/"+export.ID.String())
+
+	err = common.WriteJSON(w, http.StatusAccepted, common.Envelope{"export": newExportResponse(export)}, headers)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// GetExport godoc
+// @Summary Get the status of an export
+// @Description Get the status of an export of the current user. Ready exports can be downloaded until they expire.
+// @Tags account
+// @Produce json
+// @Param id path string true "Export ID" format(uuid)
+// @Success 200 {object} ExportResponse
+// @Security BearerAuth
+// @Router /me/exports/{id} [get]
+func (h *AccountHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := h.findExport(w, r)
+	if !ok {
+		return
+	}
+
+	err := common.WriteJSON(w, http.StatusOK, common.Envelope{"export": newExportResponse(export)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// DownloadExport godoc
+// @Summary Download an export
+// @Description Download the ZIP archive of a ready export of the current user
+// @Tags account
+// @Produce application/zip
+// @Param id path string true "Export ID" format(uuid)
+// @Success 200 {file} file
+// @Security BearerAuth
+// @Router /me/exports/{id}/download [get]
+func (h *AccountHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := h.findExport(w, r)
+	if !ok {
+		return
+	}
+
+	if export.Status != ExportStatusReady {
+		common.ConflictResponse(w, r, ErrExportNotReady)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="book-reviews-export-%s.zip"`, export.CreatedAt.Format("2006-01-02")))
+	w.Header().Set("Content-Length", strconv.Itoa(len(export.Archive)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(export.Archive)
+}
+
+// findExport returns the export of the current user in the request path, or
+// writes an error response.
+func (h *AccountHandler) findExport(w http.ResponseWriter, r *http.Request) (*Export, bool) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return nil, false
+	}
+
+	u, _ := auth.ContextGetUser(r)
+
+	export, err := h.service.GetExport(u.ID.String(), id)
+
+	if err != nil {
+		switch err {
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return export, true
+}
+
+// ScheduleDeletion godoc
+// @Summary Delete your account
+// @Description Schedule the account of the current user for deletion. Until the grace period has passed the user can still log in and cancel. Afterwards the account and all personal data are deleted, and contributed content is kept without a link to the user.
+// @Tags account
+// @Produce json
+// @Success 202 {object} DeletionResponse
+// @Security BearerAuth
+// @Router /me/deletion [post]
+func (h *AccountHandler) ScheduleDeletion(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.ContextGetUser(r)
+
+	scheduled, err := h.service.ScheduleDeletion(u.ID.String())
+
+	if err != nil {
+		switch err {
+		case user.ErrDeletionScheduled:
+			common.ConflictResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusAccepted, common.Envelope{"deletion": DeletionResponse{DeletionScheduledAt: *scheduled.DeletionScheduledAt}}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// CancelDeletion godoc
+// @Summary Keep your account
+// @Description Cancel the scheduled deletion of the account of the current user
+// @Tags account
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Security BearerAuth
+// @Router /me/deletion [delete]
+func (h *AccountHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.ContextGetUser(r)
+
+	err := h.service.CancelDeletion(u.ID.String())
+
+	if err != nil {
+		switch err {
+		case user.ErrNoDeletionScheduled:
+			common.ConflictResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Successfully cancelled the deletion of your account"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,136 @@
+//go:build unit
+// +build unit
+
+package account
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/auth"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// newExportRequest returns a request for an export of u with the ID in the
+// route context.
+func newExportRequest(path, id string, u *user.User) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	return auth.ContextSetUser(r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)), u)
+}
+
+func TestExportHandlers(t *testing.T) {
+	mockService := new(MockAccountService)
+	handler := NewAccountHandler(mockService)
+
+	u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+	t.Run("POST Request export handler: Export is accepted", func(t *testing.T) {
+		export := &Export{ID: uuid.New(), UserID: u.ID, Status: ExportStatusPending, CreatedAt: time.Now()}
+
+		mockService.On("RequestExport", u.ID.String()).Return(export, nil).Once()
+
+		r := auth.ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/me/export", nil), u)
+		w := httptest.NewRecorder()
+
+		handler.RequestExport(w, r)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "/v1/api/me/exports/"+export.ID.String(), w.Header().Get("Location"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Request export handler: Export in progress", func(t *testing.T) {
+		mockService.On("RequestExport", u.ID.String()).Return((*Export)(nil), ErrExportInProgress).Once()
+
+		r := auth.ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/me/export", nil), u)
+		w := httptest.NewRecorder()
+
+		handler.RequestExport(w, r)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GET Download export handler: Successfully download", func(t *testing.T) {
+		export := &Export{ID: uuid.New(), UserID: u.ID, Status: ExportStatusReady, Archive: []byte("PK archive"), CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+		mockService.On("GetExport", u.ID.String(), export.ID.String()).Return(export, nil).Once()
+
+		w := httptest.NewRecorder()
+
+		handler.DownloadExport(w, newExportRequest("/v1/api/me/exports/"+export.ID.String()+"/download", export.ID.String(), u))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="book-reviews-export-2024-01-01.zip"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "PK archive", w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GET Download export handler: Export not ready", func(t *testing.T) {
+		export := &Export{ID: uuid.New(), UserID: u.ID, Status: ExportStatusPending}
+
+		mockService.On("GetExport", u.ID.String(), export.ID.String()).Return(export, nil).Once()
+
+		w := httptest.NewRecorder()
+
+		handler.DownloadExport(w, newExportRequest("/v1/api/me/exports/"+export.ID.String()+"/download", export.ID.String(), u))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GET Export handler: Export of another user", func(t *testing.T) {
+		id := uuid.NewString()
+
+		mockService.On("GetExport", u.ID.String(), id).Return((*Export)(nil), common.ErrNotFound).Once()
+
+		w := httptest.NewRecorder()
+
+		handler.GetExport(w, newExportRequest("/v1/api/me/exports/"+id, id, u))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestDeletionHandlers(t *testing.T) {
+	mockService := new(MockAccountService)
+	handler := NewAccountHandler(mockService)
+
+	u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+	t.Run("POST Schedule deletion handler: Already scheduled", func(t *testing.T) {
+		mockService.On("ScheduleDeletion", u.ID.String()).Return((*user.User)(nil), user.ErrDeletionScheduled).Once()
+
+		r := auth.ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/me/deletion", nil), u)
+		w := httptest.NewRecorder()
+
+		handler.ScheduleDeletion(w, r)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("DELETE Cancel deletion handler: Successfully cancel", func(t *testing.T) {
+		mockService.On("CancelDeletion", u.ID.String()).Return(nil).Once()
+
+		r := auth.ContextSetUser(httptest.NewRequest(http.MethodDelete, "/v1/api/me/deletion", nil), u)
+		w := httptest.NewRecorder()
+
+		handler.CancelDeletion(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
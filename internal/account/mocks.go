@@ -0,0 +1,75 @@
+package account
+
+import (
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockExportRepository struct {
+	mock.Mock
+}
+
+type MockDeletionRepository struct {
+	mock.Mock
+}
+
+type MockAccountService struct {
+	mock.Mock
+}
+
+func (m *MockExportRepository) Save(export *Export) (*Export, error) {
+	args := m.Called(export)
+	return args.Get(0).(*Export), args.Error(1)
+}
+
+func (m *MockExportRepository) FindById(userID, id string) (*Export, error) {
+	args := m.Called(userID, id)
+	return args.Get(0).(*Export), args.Error(1)
+}
+
+func (m *MockExportRepository) Complete(id string, archive []byte, expiresAt time.Time) error {
+	args := m.Called(id, archive, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockExportRepository) Fail(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockExportRepository) DeleteExpired(now time.Time) error {
+	args := m.Called(now)
+	return args.Error(0)
+}
+
+func (m *MockDeletionRepository) DeleteUser(userID string, dueBy time.Time) error {
+	args := m.Called(userID, dueBy)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) RequestExport(userID string) (*Export, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*Export), args.Error(1)
+}
+
+func (m *MockAccountService) GetExport(userID, id string) (*Export, error) {
+	args := m.Called(userID, id)
+	return args.Get(0).(*Export), args.Error(1)
+}
+
+func (m *MockAccountService) ScheduleDeletion(userID string) (*user.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockAccountService) CancelDeletion(userID string) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) Purge() error {
+	args := m.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,186 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/database"
+)
+
+type ExportRepository interface {
+	Save(export *Export) (*Export, error)
+	FindById(userID, id string) (*Export, error)
+	Complete(id string, archive []byte, expiresAt time.Time) error
+	Fail(id string) error
+	DeleteExpired(now time.Time) error
+}
+
+type exportRepository struct {
+	db *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) ExportRepository {
+	return &exportRepository{
+		db: db,
+	}
+}
+
+func (r *exportRepository) Save(export *Export) (*Export, error) {
+	query := `
+		INSERT INTO data_exports (id, user_id, status, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, export.ID, export.UserID, export.Status, export.ExpiresAt).Scan(&export.CreatedAt)
+
+	if err != nil {
+		switch {
+		case database.IsUniqueViolation(err):
+			return nil, ErrExportInProgress
+		default:
+			return nil, err
+		}
+	}
+
+	return export, nil
+}
+
+func (r *exportRepository) FindById(userID, id string) (*Export, error) {
+	query := `
+		SELECT id, user_id, status, archive, completed_at, expires_at, created_at
+		FROM data_exports
+		WHERE id = $1 AND user_id = $2`
+
+	var export Export
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, id, userID).Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.Archive,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+		&export.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, common.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &export, nil
+}
+
+func (r *exportRepository) Complete(id string, archive []byte, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'ready', archive = $1, completed_at = CURRENT_TIMESTAMP, expires_at = $2
+		WHERE id = $3 AND status = 'pending'`
+
+	return r.exec(query, archive, expiresAt, id)
+}
+
+func (r *exportRepository) Fail(id string) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'failed', completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'`
+
+	return r.exec(query, id)
+}
+
+func (r *exportRepository) DeleteExpired(now time.Time) error {
+	query := `
+		DELETE FROM data_exports
+		WHERE expires_at <= $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, now)
+	return err
+}
+
+func (r *exportRepository) exec(query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return common.ErrNotFound
+	}
+
+	return nil
+}
+
+type DeletionRepository interface {
+	DeleteUser(userID string, dueBy time.Time) error
+}
+
+type deletionRepository struct {
+	db *sql.DB
+}
+
+func NewDeletionRepository(db *sql.DB) DeletionRepository {
+	return &deletionRepository{
+		db: db,
+	}
+}
+
+// DeleteUser yields ErrNotFound for users whose deletion is not due, for
+// example because it was cancelled.
+func (r *deletionRepository) DeleteUser(userID string, dueBy time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return database.Transaction(ctx, r.db, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM users
+			WHERE id = $1 AND deletion_scheduled_at <= $2`
+
+		result, err := tx.ExecContext(ctx, query, userID, dueBy)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return common.ErrNotFound
+		}
+
+		query = `
+			UPDATE book_edit_suggestions
+			SET submitted_by = CASE WHEN submitted_by = $1 THEN '' ELSE submitted_by END,
+				reviewed_by = CASE WHEN reviewed_by = $1 THEN '' ELSE reviewed_by END
+			WHERE submitted_by = $1 OR reviewed_by = $1`
+
+		_, err = tx.ExecContext(ctx, query, userID)
+		return err
+	})
+}
@@ -0,0 +1,154 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/auth"
+	"github.com/jakottelaar/gobookreviewapp/internal/suggestion"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+)
+
+type AccountService interface {
+	RequestExport(userID string) (*Export, error)
+	GetExport(userID, id string) (*Export, error)
+	ScheduleDeletion(userID string) (*user.User, error)
+	CancelDeletion(userID string) error
+	Purge() error
+}
+
+type accountService struct {
+	exports     ExportRepository
+	deletions   DeletionRepository
+	users       user.UserService
+	auth        auth.AuthService
+	suggestions suggestion.SuggestionService
+	// gracePeriod is how long a user can cancel the deletion of their
+	// account.
+	gracePeriod time.Duration
+	// background runs work that requests do not wait for.
+	background func(func())
+}
+
+func NewAccountService(exports ExportRepository, deletions DeletionRepository, users user.UserService, authService auth.AuthService, suggestions suggestion.SuggestionService, gracePeriod time.Duration) AccountService {
+	return &accountService{
+		exports:     exports,
+		deletions:   deletions,
+		users:       users,
+		auth:        authService,
+		suggestions: suggestions,
+		gracePeriod: gracePeriod,
+		background:  func(fn func()) { go fn() },
+	}
+}
+
+// RequestExport starts building an export of all data of a user. The export
+// is returned pending; its status shows when it can be downloaded.
+func (s *accountService) RequestExport(userID string) (*Export, error) {
+	export, err := s.exports.Save(&Export{
+		ID:        uuid.New(),
+		UserID:    uuid.MustParse(userID),
+		Status:    ExportStatusPending,
+		ExpiresAt: time.Now().Add(exportBuildTimeout),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.background(func() {
+		err := s.buildExport(export)
+		if err != nil {
+			slog.Error("Could not export user data", "export", export.ID, "error", err)
+		}
+	})
+
+	return export, nil
+}
+
+// buildExport builds the archive of an export and stores it, or marks the
+// export as failed.
+func (s *accountService) buildExport(export *Export) error {
+	archive, err := s.buildArchive(export.UserID.String())
+	if err != nil {
+		failErr := s.exports.Fail(export.ID.String())
+		if failErr != nil {
+			slog.Error("Could not mark export as failed", "export", export.ID, "error", failErr)
+		}
+
+		return err
+	}
+
+	return s.exports.Complete(export.ID.String(), archive, time.Now().Add(exportTTL))
+}
+
+func (s *accountService) GetExport(userID, id string) (*Export, error) {
+	return s.exports.FindById(userID, id)
+}
+
+// ScheduleDeletion schedules the account of a user to be deleted once the
+// grace period has passed.
+func (s *accountService) ScheduleDeletion(userID string) (*user.User, error) {
+	return s.users.ScheduleDeletion(userID, time.Now().Add(s.gracePeriod))
+}
+
+func (s *accountService) CancelDeletion(userID string) error {
+	return s.users.CancelDeletion(userID)
+}
+
+// RunPurge purges once right away and then every purgeInterval, until ctx is
+// done.
+func RunPurge(ctx context.Context, service AccountService) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		err := service.Purge()
+		if err != nil {
+			slog.Error("Could not purge accounts", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes expired exports and the accounts whose grace period has
+// passed. It is run periodically. An account that cannot be deleted is logged
+// and retried by the next purge, so it does not hold up the others.
+func (s *accountService) Purge() error {
+	now := time.Now()
+
+	err := s.exports.DeleteExpired(now)
+	if err != nil {
+		return err
+	}
+
+	ids, err := s.users.ListDueForDeletion(now)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := s.deletions.DeleteUser(id, now)
+		if errors.Is(err, common.ErrNotFound) {
+			// The deletion was cancelled after the users were listed
+			continue
+		}
+
+		if err != nil {
+			slog.Error("Could not delete account", "user", id, "error", err)
+			continue
+		}
+
+		slog.Info("Deleted account", "user", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+//go:build unit
+// +build unit
+
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/auth"
+	"github.com/jakottelaar/gobookreviewapp/internal/suggestion"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAccountService returns a service that builds exports before
+// returning from RequestExport.
+func newTestAccountService(exports ExportRepository, deletions DeletionRepository, users user.UserService, authService auth.AuthService, suggestions suggestion.SuggestionService) AccountService {
+	service := NewAccountService(exports, deletions, users, authService, suggestions, 30*24*time.Hour)
+	service.(*accountService).background = func(fn func()) { fn() }
+
+	return service
+}
+
+// readArchive returns the files of a ZIP archive by name.
+func readArchive(t *testing.T, archive []byte) map[string][]byte {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+
+	for _, file := range reader.File {
+		f, err := file.Open()
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		f.Close()
+
+		files[file.Name] = data
+	}
+
+	return files
+}
+
+func TestRequestExportAccountService(t *testing.T) {
+	u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com", Name: "Jane Doe", PasswordHash: []byte("hash"), TOTPSecret: []byte("secret")}
+
+	t.Run("Request export account service: Build the archive", func(t *testing.T) {
+		mockExports := new(MockExportRepository)
+		mockUsers := new(user.MockUserService)
+		mockAuth := new(auth.MockAuthService)
+		mockSuggestions := new(suggestion.MockSuggestionService)
+		service := newTestAccountService(mockExports, new(MockDeletionRepository), mockUsers, mockAuth, mockSuggestions)
+
+		mockExports.On("Save", mock.MatchedBy(func(export *Export) bool {
+			return export.UserID == u.ID && export.Status == ExportStatusPending
+		})).Return(&Export{ID: uuid.New(), UserID: u.ID, Status: ExportStatusPending}, nil)
+		mockUsers.On("GetUserById", u.ID.String()).Return(u, nil)
+		mockUsers.On("GetProfile", u.ID.String()).Return(&user.Profile{UserID: u.ID, DisplayName: "Jane", Bio: "Reads mostly classics."}, nil)
+		mockAuth.On("ListSessions", u.ID.String()).Return([]auth.Session{{ID: uuid.New(), UserAgent: "curl/8.5.0", IPAddress: "203.0.113.7"}}, nil)
+		mockAuth.On("ListAPIKeys", u.ID.String()).Return([]auth.APIKey{{ID: uuid.New(), Name: "read only", Prefix: "gbr_abcdefgh", KeyHash: []byte("hash"), Scopes: []string{"books:read"}}}, nil)
+		mockSuggestions.On("ListBySubmitter", u.ID.String()).Return([]suggestion.Suggestion{{ID: uuid.New(), Comment: "Page count was wrong", Status: suggestion.StatusPending}}, nil)
+		mockUsers.On("ListIdentities", u.ID.String()).Return([]user.Identity{{ID: uuid.New(), UserID: u.ID, Issuer: "https://idp.example.com", Subject: "jane-1234", Email: "jane@idp.example.com"}}, nil)
+		mockUsers.On("ListTokens", u.ID.String()).Return([]user.Token{{ID: uuid.New(), UserID: u.ID, Purpose: user.TokenPurposePasswordReset, TokenHash: []byte("token-hash")}}, nil)
+		mockSuggestions.On("ListByReviewer", u.ID.String()).Return([]suggestion.Suggestion{{ID: uuid.New(), Status: suggestion.StatusRejected, ReviewComment: "No source for the new year"}}, nil)
+
+		var archive []byte
+		mockExports.On("Complete", mock.AnythingOfType("string"), mock.Anything, mock.MatchedBy(func(expiresAt time.Time) bool {
+			return expiresAt.After(time.Now().Add(6 * 24 * time.Hour))
+		})).Run(func(args mock.Arguments) {
+			archive = args.Get(1).([]byte)
+		}).Return(nil)
+
+		export, err := service.RequestExport(u.ID.String())
+
+		require.NoError(t, err)
+		assert.Equal(t, ExportStatusPending, export.Status)
+		require.NotNil(t, archive)
+
+		files := readArchive(t, archive)
+		assert.Contains(t, files, "README.txt")
+
+		var account map[string]interface{}
+		require.NoError(t, json.Unmarshal(files["account.json"], &account))
+		assert.Equal(t, "jane.doe@example.com", account["email"])
+		assert.Equal(t, "Jane", account["profile"].(map[string]interface{})["display_name"])
+
+		assert.NotContains(t, account, "password_hash")
+		assert.NotContains(t, account, "totp_secret")
+		assert.NotContains(t, string(files["api_keys.json"]), "key_hash")
+
+		assert.Contains(t, string(files["sessions.json"]), "203.0.113.7")
+		assert.Contains(t, string(files["api_keys.json"]), "gbr_abcdefgh")
+		assert.Contains(t, string(files["suggestions.json"]), "Page count was wrong")
+		assert.Contains(t, string(files["identities.json"]), "jane-1234")
+		assert.Contains(t, string(files["identities.json"]), "jane@idp.example.com")
+		assert.Contains(t, string(files["tokens.json"]), user.TokenPurposePasswordReset)
+		assert.NotContains(t, string(files["tokens.json"]), "token_hash")
+		assert.Contains(t, string(files["reviews.json"]), "No source for the new year")
+
+		mockExports.AssertExpectations(t)
+	})
+
+	t.Run("Request export account service: Failed export", func(t *testing.T) {
+		mockExports := new(MockExportRepository)
+		mockUsers := new(user.MockUserService)
+		service := newTestAccountService(mockExports, new(MockDeletionRepository), mockUsers, new(auth.MockAuthService), new(suggestion.MockSuggestionService))
+
+		exportID := uuid.New()
+
+		mockExports.On("Save", mock.Anything).Return(&Export{ID: exportID, UserID: u.ID, Status: ExportStatusPending}, nil)
+		mockUsers.On("GetUserById", u.ID.String()).Return((*user.User)(nil), errors.New("connection refused"))
+		mockExports.On("Fail", exportID.String()).Return(nil).Once()
+
+		_, err := service.RequestExport(u.ID.String())
+
+		require.NoError(t, err)
+		mockExports.AssertExpectations(t)
+		mockExports.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Request export account service: Export in progress", func(t *testing.T) {
+		mockExports := new(MockExportRepository)
+		service := newTestAccountService(mockExports, new(MockDeletionRepository), new(user.MockUserService), new(auth.MockAuthService), new(suggestion.MockSuggestionService))
+
+		mockExports.On("Save", mock.Anything).Return((*Export)(nil), ErrExportInProgress)
+
+		_, err := service.RequestExport(u.ID.String())
+
+		require.ErrorIs(t, err, ErrExportInProgress)
+	})
+}
+
+func TestDeletionAccountService(t *testing.T) {
+	userID := uuid.NewString()
+
+	t.Run("Deletion account service: Schedule after the grace period", func(t *testing.T) {
+		mockUsers := new(user.MockUserService)
+		service := newTestAccountService(new(MockExportRepository), new(MockDeletionRepository), mockUsers, new(auth.MockAuthService), new(suggestion.MockSuggestionService))
+
+		mockUsers.On("ScheduleDeletion", userID, mock.MatchedBy(func(at time.Time) bool {
+			return time.Until(at).Round(time.Hour) == 30*24*time.Hour
+		})).Return(&user.User{}, nil).Once()
+
+		_, err := service.ScheduleDeletion(userID)
+
+		require.NoError(t, err)
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("Deletion account service: Purge deletes due accounts", func(t *testing.T) {
+		mockExports := new(MockExportRepository)
+		mockDeletions := new(MockDeletionRepository)
+		mockUsers := new(user.MockUserService)
+		service := newTestAccountService(mockExports, mockDeletions, mockUsers, new(auth.MockAuthService), new(suggestion.MockSuggestionService))
+
+		var now time.Time
+
+		mockExports.On("DeleteExpired", mock.AnythingOfType("time.Time")).Return(nil).Once()
+		mockUsers.On("ListDueForDeletion", mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+			now = args.Get(0).(time.Time)
+		}).Return([]string{userID}, nil).Once()
+		mockDeletions.On("DeleteUser", userID, mock.MatchedBy(func(dueBy time.Time) bool {
+			return dueBy.Equal(now)
+		})).Return(nil).Once()
+
+		require.NoError(t, service.Purge())
+
+		mockExports.AssertExpectations(t)
+		mockUsers.AssertExpectations(t)
+		mockDeletions.AssertExpectations(t)
+	})
+
+	t.Run("Deletion account service: Purge continues after a failed account", func(t *testing.T) {
+		mockExports := new(MockExportRepository)
+		mockDeletions := new(MockDeletionRepository)
+		mockUsers := new(user.MockUserService)
+		service := newTestAccountService(mockExports, mockDeletions, mockUsers, new(auth.MockAuthService), new(suggestion.MockSuggestionService))
+
+		failingID := uuid.NewString()
+		cancelledID := uuid.NewString()
+
+		mockExports.On("DeleteExpired", mock.Anything).Return(nil)
+		mockUsers.On("ListDueForDeletion", mock.Anything).Return([]string{failingID, cancelledID, userID}, nil)
+		mockDeletions.On("DeleteUser", failingID, mock.Anything).Return(errors.New("connection refused")).Once()
+		mockDeletions.On("DeleteUser", cancelledID, mock.Anything).Return(common.ErrNotFound).Once()
+		mockDeletions.On("DeleteUser", userID, mock.Anything).Return(nil).Once()
+
+		require.NoError(t, service.Purge())
+		mockDeletions.AssertExpectations(t)
+	})
+}
+
+func TestRunPurge(t *testing.T) {
+	t.Run("Run purge: Purge at startup and stop with the context", func(t *testing.T) {
+		mockService := new(MockAccountService)
+		mockService.On("Purge").Return(errors.New("connection refused")).Once()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			RunPurge(ctx, mockService)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("RunPurge did not stop after the context was done")
+		}
+
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/jwt"
+)
+
+const (
+	ScopeBooksRead  = "books:read"
+	ScopeBooksWrite = "books:write"
+	ScopeAdmin      = "admin"
+)
+
+// APIKeyPrefix starts every API key, so keys can be told apart from access
+// tokens and found by secret scanners.
+const APIKeyPrefix = "gbr_"
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired token")
+	ErrTokenRevoked = errors.New("the token has already been revoked")
+)
+
+// TokenConfig configures how tokens are signed and how long they live.
+type TokenConfig struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// RefreshToken is a stored refresh token. Only a hash of the token is kept.
+// Rotating a token revokes it and issues a new token in the same family, so
+// the reuse of a revoked token can revoke every token descended from the
+// same login.
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	TokenHash []byte
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// Session is a login on one device. Its ID is the family of the refresh
+// tokens issued for the login, and access tokens carry it in their sid
+// claim, so revoking a session ends the login immediately.
+type Session struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	UserAgent  string
+	IPAddress  string
+	LastUsedAt time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// Client describes the device a request came from.
+type Client struct {
+	UserAgent string
+	IPAddress string
+}
+
+// APIKey is a stored API key. Only a hash of the key is kept, together with
+// its first characters so users can tell their keys apart. A key acts on
+// behalf of the user that created it, limited to its scopes.
+type APIKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    []byte
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// HasScope reports whether the key grants scope. The admin scope grants
+// every scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Every token signed with the secret names its kind in the typ claim, and
+// parsers only accept their own kind. A challenge or login flow token can
+// therefore never pass as an access token, whatever other claims it has.
+const (
+	tokenTypeAccess             = "access"
+	tokenTypeTwoFactorChallenge = "two_factor_challenge"
+	tokenTypeOIDCFlow           = "oidc_flow"
+)
+
+// tokenClaims are the claims shared by all tokens signed with the secret.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Type string `json:"typ"`
+}
+
+func (c tokenClaims) tokenType() string {
+	return c.Type
+}
+
+type typedClaims interface {
+	jwt.Claims
+	tokenType() string
+}
+
+// parseToken verifies token like jwt.Parse and rejects it unless it is of
+// kind typ.
+func parseToken(token string, secret []byte, typ string, claims typedClaims) error {
+	err := jwt.Parse(token, secret, claims)
+	if err != nil {
+		return err
+	}
+
+	if claims.tokenType() != typ {
+		return jwt.ErrInvalidToken
+	}
+
+	return nil
+}
+
+// AccessClaims is the payload of an access token. The subject is the user ID.
+type AccessClaims struct {
+	tokenClaims
+	SessionID string `json:"sid,omitempty"`
+}
+
+type Tokens struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required" example:"q8Yw2b3Ri2n1tYkYH1Yk3o5nUtzp0y2M6f0dP9Ev1xA"`
+}
+
+type TokenResponse struct {
+	AccessToken           string    `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiIxMjMifQ.signature"`
+	TokenType             string    `json:"token_type" example:"Bearer"`
+	ExpiresIn             int       `json:"expires_in" example:"900"`
+	RefreshToken          string    `json:"refresh_token" example:"q8Yw2b3Ri2n1tYkYH1Yk3o5nUtzp0y2M6f0dP9Ev1xA"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at" example:"2024-01-31T00:00:00Z"`
+}
+
+func newTokenResponse(tokens *Tokens) TokenResponse {
+	return TokenResponse{
+		AccessToken:           tokens.AccessToken,
+		TokenType:             "Bearer",
+		ExpiresIn:             int(time.Until(tokens.AccessTokenExpiresAt).Round(time.Second).Seconds()),
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+	}
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJ1aWQiOiIxMjMifQ.signature"`
+	Code           string `json:"code" validate:"required,max=32" example:"123456"`
+}
+
+// TwoFactorChallengeResponse is returned by a login that needs a second
+// factor to complete.
+type TwoFactorChallengeResponse struct {
+	ChallengeToken string `json:"challenge_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJ1aWQiOiIxMjMifQ.signature"`
+	ExpiresIn      int    `json:"expires_in" example:"300"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100" example:"nightly ingestion"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=books:read books:write admin" example:"books:read,books:write"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" validate:"omitnil,gt" example:"2025-01-01T00:00:00Z"`
+}
+
+type APIKeyResponse struct {
+	ID         string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Name       string     `json:"name" example:"nightly ingestion"`
+	Prefix     string     `json:"prefix" example:"gbr_q8Yw2b3R"`
+	Scopes     []string   `json:"scopes" example:"books:read,books:write"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2025-01-01T00:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2024-01-01T00:00:00Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" example:"2024-01-01T00:00:00Z"`
+	CreatedAt  time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// CreateAPIKeyResponse is the only response that contains the key itself.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"gbr_q8Yw2b3Ri2n1tYkYH1Yk3o5nUtzp0y2M6f0dP9Ev1xA"`
+}
+
+func newAPIKeyResponse(key *APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Device     string    `json:"device" example:"Firefox on Linux"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0"`
+	IPAddress  string    `json:"ip_address" example:"203.0.113.7"`
+	Current    bool      `json:"current" example:"true"`
+	LastUsedAt time.Time `json:"last_used_at" example:"2024-01-01T00:00:00Z"`
+	CreatedAt  time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+func newSessionResponse(session *Session, currentID string) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID.String(),
+		Device:     describeDevice(session.UserAgent),
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		Current:    session.ID.String() == currentID,
+		LastUsedAt: session.LastUsedAt,
+		CreatedAt:  session.CreatedAt,
+	}
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	URI    string `json:"otpauth_uri" example:"otpauth://totp/Book%20Reviews:jane.doe@example.com?algorithm=SHA1&digits=6&issuer=Book+Reviews&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+func newTwoFactorEnrollmentResponse(enrollment *user.TwoFactorEnrollment) TwoFactorEnrollmentResponse {
+	return TwoFactorEnrollmentResponse{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+)
+
+type contextKey string
+
+const (
+	apiKeyContextKey  = contextKey("apiKey")
+	sessionContextKey = contextKey("session")
+)
+
+// ContextSetUser returns a copy of r carrying the authenticated user. The
+// user is kept under a key of the user package, so its handlers can read it.
+func ContextSetUser(r *http.Request, u *user.User) *http.Request {
+	return user.ContextSetUser(r, u)
+}
+
+// ContextGetUser returns the authenticated user of r, if any.
+func ContextGetUser(r *http.Request) (*user.User, bool) {
+	return user.ContextGetUser(r)
+}
+
+// ContextSetAPIKey returns a copy of r carrying the API key it was
+// authenticated with.
+func ContextSetAPIKey(r *http.Request, key *APIKey) *http.Request {
+	ctx := context.WithValue(r.Context(), apiKeyContextKey, key)
+	return r.WithContext(ctx)
+}
+
+// ContextGetAPIKey returns the API key r was authenticated with, if any.
+func ContextGetAPIKey(r *http.Request) (*APIKey, bool) {
+	key, ok := r.Context().Value(apiKeyContextKey).(*APIKey)
+	return key, ok
+}
+
+// ContextSetSessionID returns a copy of r carrying the ID of the session its
+// access token belongs to.
+func ContextSetSessionID(r *http.Request, id string) *http.Request {
+	ctx := context.WithValue(r.Context(), sessionContextKey, id)
+	return r.WithContext(ctx)
+}
+
+// ContextGetSessionID returns the ID of the session r was authenticated with,
+// if any.
+func ContextGetSessionID(r *http.Request) (string, bool) {
+	id, ok := r.Context().Value(sessionContextKey).(string)
+	return id, ok
+}
@@ -0,0 +1,883 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+)
+
+type AuthHandler struct {
+	service AuthService
+	users   user.UserService
+}
+
+func NewAuthHandler(service AuthService, users user.UserService) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+		users:   users,
+	}
+}
+
+// Login godoc
+// @Summary Log in with email and password
+// @Description Exchange credentials for a short-lived access token and a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body user.LoginRequest true "Credentials"
+// @Success 200 {object} TokenResponse
+// @Success 202 {object} TwoFactorChallengeResponse "Users with two-factor authentication enabled complete the login at /auth/login/two-factor"
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req user.LoginRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	tokens, err := h.service.Login(&req, newClient(r))
+
+	if err != nil {
+		var challenge *TwoFactorChallenge
+		if errors.As(err, &challenge) {
+			h.writeTwoFactorChallenge(w, r, challenge)
+			return
+		}
+
+		switch err {
+		case user.ErrInvalidCredentials:
+			common.InvalidCredentialsResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	h.writeTokens(w, r, tokens)
+}
+
+// LoginTwoFactor godoc
+// @Summary Complete a login with a second factor
+// @Description Exchange the challenge token of a login and a code from an authenticator app, or a recovery code, for tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param login body TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} TokenResponse
+// @Router /auth/login/two-factor [post]
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	tokens, err := h.service.LoginTwoFactor(&req, newClient(r))
+
+	if err != nil {
+		switch err {
+		case ErrInvalidTwoFactorChallenge, user.ErrInvalidCode, user.ErrTwoFactorNotSetUp:
+			common.InvalidCredentialsResponse(w, r)
+		case user.ErrTwoFactorLocked:
+			common.RateLimitExceededResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	h.writeTokens(w, r, tokens)
+}
+
+// Refresh godoc
+// @Summary Refresh an access token
+// @Description Exchange a refresh token for a new access token and refresh token. The presented refresh token is revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenResponse
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	tokens, err := h.service.Refresh(req.RefreshToken, newClient(r))
+
+	if err != nil {
+		switch err {
+		case ErrInvalidToken:
+			common.InvalidAuthenticationTokenResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	h.writeTokens(w, r, tokens)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke a refresh token and every token refreshed from the same login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body RefreshRequest true "Refresh token"
+// @Success 200 {object} map[string]string
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	err = h.service.Logout(req.RefreshToken)
+
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Successfully logged out"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create an API key that acts on behalf of the current user, limited to the given scopes. The key is only returned in this response.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body CreateAPIKeyRequest true "API key details"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Security BearerAuth
+// @Router /api-keys [post]
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	var req CreateAPIKeyRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	key, plain, err := h.service.CreateAPIKey(u.ID.String(), &req)
+
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	resp := CreateAPIKeyResponse{APIKeyResponse: newAPIKeyResponse(key), Key: plain}
+
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	err = common.WriteJSON(w, http.StatusCreated, common.Envelope{"api_key": resp}, headers)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List the API keys of the current user, newest first
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 200 {array} APIKeyResponse
+// @Security BearerAuth
+// @Router /api-keys [get]
+func (h *AuthHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	keys, err := h.service.ListAPIKeys(u.ID.String())
+
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	resp := make([]APIKeyResponse, len(keys))
+	for i := range keys {
+		resp[i] = newAPIKeyResponse(&keys[i])
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"api_keys": resp}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key of the current user
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID" format(uuid)
+// @Success 200 {object} map[string]string
+// @Security BearerAuth
+// @Router /api-keys/{id} [delete]
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	err = h.service.RevokeAPIKey(u.ID.String(), id)
+
+	if err != nil {
+		switch err {
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Successfully revoked API key"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ListSessions godoc
+// @Summary List sessions
+// @Description List the devices the current user is logged in on, most recently used first
+// @Tags sessions
+// @Produce json
+// @Success 200 {array} SessionResponse
+// @Security BearerAuth
+// @Router /me/sessions [get]
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+	current, _ := ContextGetSessionID(r)
+
+	sessions, err := h.service.ListSessions(u.ID.String())
+
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	response := make([]SessionResponse, len(sessions))
+	for i := range sessions {
+		response[i] = newSessionResponse(&sessions[i], current)
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"sessions": response}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Log the current user out on a device. Its refresh token stops working and its access tokens are rejected.
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID" format(uuid)
+// @Success 200 {object} map[string]string
+// @Security BearerAuth
+// @Router /me/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	err = h.service.RevokeSession(u.ID.String(), id)
+
+	if err != nil {
+		switch err {
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Successfully revoked session"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// BeginTwoFactor godoc
+// @Summary Start setting up two-factor authentication
+// @Description Create a TOTP secret for the current user to add to an authenticator app. Two-factor authentication is enabled once a code is confirmed.
+// @Tags two-factor
+// @Produce json
+// @Success 200 {object} TwoFactorEnrollmentResponse
+// @Security BearerAuth
+// @Router /me/two-factor [post]
+func (h *AuthHandler) BeginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	enrollment, err := h.users.BeginTwoFactor(u.ID.String())
+
+	if err != nil {
+		switch err {
+		case user.ErrTwoFactorEnabled:
+			common.ConflictResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"two_factor": newTwoFactorEnrollmentResponse(enrollment)}, headers)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Enable two-factor authentication
+// @Description Enable two-factor authentication with a code from the authenticator app. The recovery codes in the response are shown only once.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param code body user.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} map[string][]string
+// @Security BearerAuth
+// @Router /me/two-factor/confirm [post]
+func (h *AuthHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	var req user.TwoFactorCodeRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	codes, err := h.users.ConfirmTwoFactor(u.ID.String(), req.Code)
+
+	if err != nil {
+		switch err {
+		case user.ErrTwoFactorEnabled:
+			common.ConflictResponse(w, r, err)
+		case user.ErrTwoFactorNotSetUp, user.ErrInvalidCode:
+			common.BadRequestResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	h.writeRecoveryCodes(w, r, codes)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace the recovery codes
+// @Description Replace the recovery codes of the current user after checking a code. The old recovery codes stop working.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param code body user.TwoFactorCodeRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} map[string][]string
+// @Security BearerAuth
+// @Router /me/two-factor/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	var req user.TwoFactorCodeRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	codes, err := h.users.RegenerateRecoveryCodes(u.ID.String(), req.Code)
+
+	if err != nil {
+		switch err {
+		case user.ErrTwoFactorNotSetUp, user.ErrInvalidCode:
+			common.BadRequestResponse(w, r, err)
+		case user.ErrTwoFactorLocked:
+			common.RateLimitExceededResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	h.writeRecoveryCodes(w, r, codes)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Disable two-factor authentication after checking a code. Admins and moderators cannot disable it.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param code body user.TwoFactorCodeRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} map[string]string
+// @Security BearerAuth
+// @Router /me/two-factor [delete]
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	var req user.TwoFactorCodeRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	err = h.users.DisableTwoFactor(u.ID.String(), req.Code)
+
+	if err != nil {
+		switch err {
+		case user.ErrTwoFactorRequired:
+			common.TwoFactorRequiredResponse(w, r)
+		case user.ErrTwoFactorNotSetUp, user.ErrInvalidCode:
+			common.BadRequestResponse(w, r, err)
+		case user.ErrTwoFactorLocked:
+			common.RateLimitExceededResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Successfully disabled two-factor authentication"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *AuthHandler) writeRecoveryCodes(w http.ResponseWriter, r *http.Request, codes []string) {
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	err := common.WriteJSON(w, http.StatusOK, common.Envelope{"recovery_codes": codes}, headers)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Verify the email address of a user with the token from the verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body user.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} map[string]string
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req user.VerifyEmailRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	_, err = h.users.VerifyEmail(req.Token)
+
+	if err != nil {
+		switch err {
+		case user.ErrInvalidToken:
+			common.BadRequestResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Successfully verified email address"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend the verification email
+// @Description Send a new verification email to the current user. At most three emails are sent per hour.
+// @Tags auth
+// @Produce json
+// @Success 202 {object} map[string]string
+// @Security BearerAuth
+// @Router /auth/verify-email/resend [post]
+func (h *AuthHandler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	err := h.users.SendVerificationEmail(u.ID.String())
+
+	if err != nil {
+		switch err {
+		case user.ErrAlreadyVerified:
+			common.ConflictResponse(w, r, err)
+		case user.ErrTooManyRequests:
+			common.RateLimitExceededResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusAccepted, common.Envelope{"message": "A verification email has been sent"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// RequestPasswordReset godoc
+// @Summary Request a password reset
+// @Description Email a password reset link if the address belongs to a user. The response is the same whether or not it does.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email body user.PasswordResetRequest true "Email address"
+// @Success 202 {object} map[string]string
+// @Router /auth/password-reset [post]
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req user.PasswordResetRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	err = h.users.RequestPasswordReset(req.Email)
+
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusAccepted, common.Envelope{"message": "If the email address is registered, a password reset link has been sent"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password with the token from a password reset email. Every refresh token of the user is revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param reset body user.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]string
+// @Router /auth/password-reset/confirm [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req user.ResetPasswordRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	err = h.service.ResetPassword(&req)
+
+	if err != nil {
+		switch err {
+		case user.ErrInvalidToken:
+			common.BadRequestResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Successfully reset password"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// oidcFlowCookie holds the flow token of a login at the identity provider.
+const oidcFlowCookie = "oidc_flow"
+
+// OIDCLogin godoc
+// @Summary Log in with single sign-on
+// @Description Redirect to the identity provider to log in. The login continues at the callback.
+// @Tags auth
+// @Success 302
+// @Router /auth/oidc/login [get]
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, flow, err := h.service.OIDCLogin()
+
+	if err != nil {
+		switch err {
+		case ErrOIDCNotConfigured:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    flow,
+		Path:     "/v1/api/auth/oidc",
+		MaxAge:   int(oidcFlowTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCLink godoc
+// @Summary Link a single sign-on account
+// @Description Start linking an account at the identity provider to the current user. Send the user to the returned URL; the link completes at the callback. This is how accounts whose email address is not verified add single sign-on.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Security BearerAuth
+// @Router /auth/oidc/link [post]
+func (h *AuthHandler) OIDCLink(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	authURL, flow, err := h.service.OIDCLink(u.ID.String())
+
+	if err != nil {
+		switch err {
+		case ErrOIDCNotConfigured:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    flow,
+		Path:     "/v1/api/auth/oidc",
+		MaxAge:   int(oidcFlowTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"authorization_url": authURL}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// OIDCCallback godoc
+// @Summary Complete single sign-on
+// @Description The identity provider redirects here after logging in. Users logging in for the first time are linked to the account with the same email address if both sides verified it, or a new account is created. Links started at /auth/oidc/link are completed here.
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} TokenResponse
+// @Router /auth/oidc/callback [get]
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// The flow token is single use whatever the outcome.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Path:     "/v1/api/auth/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if query.Get("error") != "" {
+		common.BadRequestResponse(w, r, fmt.Errorf("identity provider: %s", query.Get("error")))
+		return
+	}
+
+	cookie, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		common.BadRequestResponse(w, r, ErrInvalidOIDCState)
+		return
+	}
+
+	tokens, err := h.service.OIDCCallback(cookie.Value, query.Get("state"), query.Get("code"), newClient(r))
+
+	if err != nil {
+		var challenge *TwoFactorChallenge
+		if errors.As(err, &challenge) {
+			h.writeTwoFactorChallenge(w, r, challenge)
+			return
+		}
+
+		switch err {
+		case ErrOIDCNotConfigured:
+			common.NotFoundResponse(w, r)
+		case ErrInvalidOIDCState, user.ErrMissingEmail:
+			common.BadRequestResponse(w, r, err)
+		case ErrOIDCLoginFailed:
+			common.InvalidCredentialsResponse(w, r)
+		case user.ErrDuplicateEmail, user.ErrIdentityLinked:
+			common.ConflictResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	h.writeTokens(w, r, tokens)
+}
+
+func (h *AuthHandler) writeTokens(w http.ResponseWriter, r *http.Request, tokens *Tokens) {
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	err := common.WriteJSON(w, http.StatusOK, common.Envelope{"tokens": newTokenResponse(tokens)}, headers)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *AuthHandler) writeTwoFactorChallenge(w http.ResponseWriter, r *http.Request, challenge *TwoFactorChallenge) {
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	response := TwoFactorChallengeResponse{
+		ChallengeToken: challenge.Token,
+		ExpiresIn:      int(time.Until(challenge.ExpiresAt).Round(time.Second).Seconds()),
+	}
+
+	err := common.WriteJSON(w, http.StatusAccepted, common.Envelope{"two_factor_challenge": response}, headers)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// validateRequest validates req with the user validation rules and writes a
+// failed validation response if it is invalid.
+func validateRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	validate := user.NewValidator()
+
+	err := validate.Struct(req)
+
+	if err != nil {
+		errors := make(map[string]string)
+
+		for _, err := range err.(validator.ValidationErrors) {
+			errors[err.Field()] = err.Tag()
+		}
+
+		common.FailedValidationResponse(w, r, errors)
+		return false
+	}
+
+	return true
+}
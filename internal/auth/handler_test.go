@@ -0,0 +1,540 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginHandler(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService, new(user.MockUserService))
+
+	t.Run("POST Login handler: Successfully log in", func(t *testing.T) {
+		req := user.LoginRequest{Email: "jane.doe@example.com", Password: "correct-Horse-battery-5taple"}
+
+		tokens := &Tokens{
+			AccessToken:           "access",
+			AccessTokenExpiresAt:  time.Now().Add(15 * time.Minute),
+			RefreshToken:          "refresh",
+			RefreshTokenExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		mockService.On("Login", &req, &Client{UserAgent: testClient.UserAgent, IPAddress: "192.0.2.1"}).Return(tokens, nil).Once()
+
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/login", bytes.NewReader(body))
+		r.Header.Set("User-Agent", testClient.UserAgent)
+		w := httptest.NewRecorder()
+
+		handler.Login(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+		var response map[string]map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, "access", response["tokens"]["access_token"])
+		assert.Equal(t, "Bearer", response["tokens"]["token_type"])
+		assert.Equal(t, float64(900), response["tokens"]["expires_in"])
+		assert.Equal(t, "refresh", response["tokens"]["refresh_token"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Login handler: Invalid credentials", func(t *testing.T) {
+		req := user.LoginRequest{Email: "jane.doe@example.com", Password: "wrong"}
+
+		mockService.On("Login", &req, mock.AnythingOfType("*auth.Client")).Return((*Tokens)(nil), user.ErrInvalidCredentials).Once()
+
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/login", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.Login(w, r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestRefreshHandler(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService, new(user.MockUserService))
+
+	t.Run("POST Refresh handler: Invalid refresh token", func(t *testing.T) {
+		mockService.On("Refresh", "revoked", mock.AnythingOfType("*auth.Client")).Return((*Tokens)(nil), ErrInvalidToken).Once()
+
+		body, _ := json.Marshal(RefreshRequest{RefreshToken: "revoked"})
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/refresh", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.Refresh(w, r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Refresh handler: Missing refresh token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/refresh", bytes.NewReader([]byte(`{}`)))
+		w := httptest.NewRecorder()
+
+		handler.Refresh(w, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestAPIKeyHandlers(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService, new(user.MockUserService))
+
+	u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+	t.Run("POST Create API key handler: Successfully create a key", func(t *testing.T) {
+		req := CreateAPIKeyRequest{Name: "ingestion", Scopes: []string{ScopeBooksRead, ScopeBooksWrite}}
+
+		key := &APIKey{ID: uuid.New(), UserID: u.ID, Name: req.Name, Prefix: "gbr_abcdefgh", KeyHash: []byte("hash"), Scopes: req.Scopes}
+
+		mockService.On("CreateAPIKey", u.ID.String(), &req).Return(key, "gbr_abcdefghsecret", nil).Once()
+
+		body, _ := json.Marshal(req)
+		r := ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/api-keys", bytes.NewReader(body)), u)
+		w := httptest.NewRecorder()
+
+		handler.CreateAPIKey(w, r)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, "gbr_abcdefghsecret", response["api_key"]["key"])
+		assert.Equal(t, "gbr_abcdefgh", response["api_key"]["prefix"])
+		assert.NotContains(t, response["api_key"], "key_hash")
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Create API key handler: Unknown scope", func(t *testing.T) {
+		body, _ := json.Marshal(CreateAPIKeyRequest{Name: "ingestion", Scopes: []string{"everything"}})
+		r := ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/api-keys", bytes.NewReader(body)), u)
+		w := httptest.NewRecorder()
+
+		handler.CreateAPIKey(w, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("DELETE Revoke API key handler: Key of another user", func(t *testing.T) {
+		keyID := uuid.New()
+
+		mockService.On("RevokeAPIKey", u.ID.String(), keyID.String()).Return(common.ErrNotFound).Once()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", keyID.String())
+
+		r := httptest.NewRequest(http.MethodDelete, "/v1/api/api-keys/"+keyID.String(), nil)
+		r = ContextSetUser(r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)), u)
+		w := httptest.NewRecorder()
+
+		handler.RevokeAPIKey(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestSessionHandlers(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService, new(user.MockUserService))
+
+	u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+	t.Run("GET List sessions handler: Current session is marked", func(t *testing.T) {
+		current := Session{ID: uuid.New(), UserID: u.ID, UserAgent: testClient.UserAgent, IPAddress: testClient.IPAddress}
+		other := Session{ID: uuid.New(), UserID: u.ID, UserAgent: "curl/8.5.0"}
+
+		mockService.On("ListSessions", u.ID.String()).Return([]Session{current, other}, nil).Once()
+
+		r := ContextSetUser(httptest.NewRequest(http.MethodGet, "/v1/api/me/sessions", nil), u)
+		r = ContextSetSessionID(r, current.ID.String())
+		w := httptest.NewRecorder()
+
+		handler.ListSessions(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string][]SessionResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.Len(t, response["sessions"], 2)
+		assert.True(t, response["sessions"][0].Current)
+		assert.Equal(t, "Firefox on Linux", response["sessions"][0].Device)
+		assert.Equal(t, testClient.IPAddress, response["sessions"][0].IPAddress)
+		assert.False(t, response["sessions"][1].Current)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("DELETE Revoke session handler: Session of another user", func(t *testing.T) {
+		sessionID := uuid.New()
+
+		mockService.On("RevokeSession", u.ID.String(), sessionID.String()).Return(common.ErrNotFound).Once()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", sessionID.String())
+
+		r := httptest.NewRequest(http.MethodDelete, "/v1/api/me/sessions/"+sessionID.String(), nil)
+		r = ContextSetUser(r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)), u)
+		w := httptest.NewRecorder()
+
+		handler.RevokeSession(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestTwoFactorHandlers(t *testing.T) {
+	mockService := new(MockAuthService)
+	mockUsers := new(user.MockUserService)
+	handler := NewAuthHandler(mockService, mockUsers)
+
+	u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com", Role: user.RoleAdmin}
+
+	t.Run("POST Login handler: Second factor required", func(t *testing.T) {
+		req := user.LoginRequest{Email: "jane.doe@example.com", Password: "correct-Horse-battery-5taple"}
+		challenge := &TwoFactorChallenge{Token: "challenge", ExpiresAt: time.Now().Add(twoFactorChallengeTTL)}
+
+		mockService.On("Login", &req, mock.AnythingOfType("*auth.Client")).Return((*Tokens)(nil), challenge).Once()
+
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/login", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.Login(w, r)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+		var response map[string]TwoFactorChallengeResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, "challenge", response["two_factor_challenge"].ChallengeToken)
+		assert.Equal(t, 300, response["two_factor_challenge"].ExpiresIn)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Login two-factor handler: Wrong code", func(t *testing.T) {
+		req := TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "000000"}
+
+		mockService.On("LoginTwoFactor", &req, mock.AnythingOfType("*auth.Client")).Return((*Tokens)(nil), user.ErrInvalidCode).Once()
+
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/login/two-factor", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.LoginTwoFactor(w, r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Login two-factor handler: Locked out", func(t *testing.T) {
+		req := TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "123456"}
+
+		mockService.On("LoginTwoFactor", &req, mock.AnythingOfType("*auth.Client")).Return((*Tokens)(nil), user.ErrTwoFactorLocked).Once()
+
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/login/two-factor", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.LoginTwoFactor(w, r)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Begin two-factor handler: Already enabled", func(t *testing.T) {
+		mockUsers.On("BeginTwoFactor", u.ID.String()).Return((*user.TwoFactorEnrollment)(nil), user.ErrTwoFactorEnabled).Once()
+
+		r := ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/me/two-factor", nil), u)
+		w := httptest.NewRecorder()
+
+		handler.BeginTwoFactor(w, r)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("POST Confirm two-factor handler: Recovery codes are returned", func(t *testing.T) {
+		codes := []string{"k3v9q-2mxta", "p7a2c-x4nbe"}
+
+		mockUsers.On("ConfirmTwoFactor", u.ID.String(), "123456").Return(codes, nil).Once()
+
+		body, _ := json.Marshal(user.TwoFactorCodeRequest{Code: "123456"})
+		r := ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/me/two-factor/confirm", bytes.NewReader(body)), u)
+		w := httptest.NewRecorder()
+
+		handler.ConfirmTwoFactor(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+		var response map[string][]string
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, codes, response["recovery_codes"])
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("DELETE Disable two-factor handler: Required for the role", func(t *testing.T) {
+		mockUsers.On("DisableTwoFactor", u.ID.String(), "123456").Return(user.ErrTwoFactorRequired).Once()
+
+		body, _ := json.Marshal(user.TwoFactorCodeRequest{Code: "123456"})
+		r := ContextSetUser(httptest.NewRequest(http.MethodDelete, "/v1/api/me/two-factor", bytes.NewReader(body)), u)
+		w := httptest.NewRecorder()
+
+		handler.DisableTwoFactor(w, r)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsers.AssertExpectations(t)
+	})
+}
+
+func TestEmailVerificationHandlers(t *testing.T) {
+	mockUsers := new(user.MockUserService)
+	handler := NewAuthHandler(new(MockAuthService), mockUsers)
+
+	t.Run("POST Verify email handler: Successfully verify an email address", func(t *testing.T) {
+		mockUsers.On("VerifyEmail", "token").Return(&user.User{ID: uuid.New()}, nil).Once()
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/verify-email", bytes.NewReader([]byte(`{"token": "token"}`)))
+		w := httptest.NewRecorder()
+
+		handler.VerifyEmail(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("POST Verify email handler: Invalid token", func(t *testing.T) {
+		mockUsers.On("VerifyEmail", "expired").Return((*user.User)(nil), user.ErrInvalidToken).Once()
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/verify-email", bytes.NewReader([]byte(`{"token": "expired"}`)))
+		w := httptest.NewRecorder()
+
+		handler.VerifyEmail(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("POST Resend verification email handler: Error responses", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{nil, http.StatusAccepted},
+			{user.ErrAlreadyVerified, http.StatusConflict},
+			{user.ErrTooManyRequests, http.StatusTooManyRequests},
+		}
+
+		u := &user.User{ID: uuid.New()}
+
+		for _, tt := range tests {
+			mockUsers.On("SendVerificationEmail", u.ID.String()).Return(tt.err).Once()
+
+			r := ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/auth/verify-email/resend", nil), u)
+			w := httptest.NewRecorder()
+
+			handler.ResendVerificationEmail(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+		}
+	})
+}
+
+func TestPasswordResetHandlers(t *testing.T) {
+	mockService := new(MockAuthService)
+	mockUsers := new(user.MockUserService)
+	handler := NewAuthHandler(mockService, mockUsers)
+
+	t.Run("POST Request password reset handler: Always accepted", func(t *testing.T) {
+		mockUsers.On("RequestPasswordReset", "john.doe@example.com").Return(nil).Once()
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/password-reset", bytes.NewReader([]byte(`{"email": "john.doe@example.com"}`)))
+		w := httptest.NewRecorder()
+
+		handler.RequestPasswordReset(w, r)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("POST Reset password handler: Successfully reset a password", func(t *testing.T) {
+		req := user.ResetPasswordRequest{Token: "token", Password: "new-Horse-battery-5taple"}
+
+		mockService.On("ResetPassword", &req).Return(nil).Once()
+
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/password-reset/confirm", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.ResetPassword(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Reset password handler: Invalid token", func(t *testing.T) {
+		req := user.ResetPasswordRequest{Token: "expired", Password: "new-Horse-battery-5taple"}
+
+		mockService.On("ResetPassword", &req).Return(user.ErrInvalidToken).Once()
+
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/password-reset/confirm", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.ResetPassword(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Reset password handler: Weak password", func(t *testing.T) {
+		body, _ := json.Marshal(user.ResetPasswordRequest{Token: "token", Password: "password"})
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/password-reset/confirm", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.ResetPassword(w, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestOIDCHandlers(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService, new(user.MockUserService))
+
+	t.Run("GET OIDC login handler: Redirect to the identity provider", func(t *testing.T) {
+		mockService.On("OIDCLogin").Return("https://sso.example.com/authorize?state=abc", "flow-token", nil).Once()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/api/auth/oidc/login", nil)
+		w := httptest.NewRecorder()
+
+		handler.OIDCLogin(w, r)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://sso.example.com/authorize?state=abc", w.Header().Get("Location"))
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "flow-token", cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+	})
+
+	t.Run("GET OIDC login handler: Not configured", func(t *testing.T) {
+		mockService.On("OIDCLogin").Return("", "", ErrOIDCNotConfigured).Once()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/api/auth/oidc/login", nil)
+		w := httptest.NewRecorder()
+
+		handler.OIDCLogin(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("POST OIDC link handler: Start linking for the current user", func(t *testing.T) {
+		u := &user.User{ID: uuid.New()}
+
+		mockService.On("OIDCLink", u.ID.String()).Return("https://sso.example.com/authorize?state=abc", "flow-token", nil).Once()
+
+		r := ContextSetUser(httptest.NewRequest(http.MethodPost, "/v1/api/auth/oidc/link", nil), u)
+		w := httptest.NewRecorder()
+
+		handler.OIDCLink(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "https://sso.example.com/authorize?state=abc")
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "flow-token", cookies[0].Value)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GET OIDC callback handler: Successfully log in", func(t *testing.T) {
+		tokens := &Tokens{
+			AccessToken:           "access",
+			AccessTokenExpiresAt:  time.Now().Add(15 * time.Minute),
+			RefreshToken:          "refresh",
+			RefreshTokenExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		mockService.On("OIDCCallback", "flow-token", "abc", "code", mock.AnythingOfType("*auth.Client")).Return(tokens, nil).Once()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/api/auth/oidc/callback?code=code&state=abc", nil)
+		r.AddCookie(&http.Cookie{Name: oidcFlowCookie, Value: "flow-token"})
+		w := httptest.NewRecorder()
+
+		handler.OIDCCallback(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, "access", response["tokens"]["access_token"])
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	})
+
+	t.Run("GET OIDC callback handler: Missing flow cookie", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/api/auth/oidc/callback?code=code&state=abc", nil)
+		w := httptest.NewRecorder()
+
+		handler.OIDCCallback(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("GET OIDC callback handler: Unverified email address already registered", func(t *testing.T) {
+		mockService.On("OIDCCallback", "flow-token", "abc", "code", mock.AnythingOfType("*auth.Client")).Return((*Tokens)(nil), user.ErrDuplicateEmail).Once()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/api/auth/oidc/callback?code=code&state=abc", nil)
+		r.AddCookie(&http.Cookie{Name: oidcFlowCookie, Value: "flow-token"})
+		w := httptest.NewRecorder()
+
+		handler.OIDCCallback(w, r)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
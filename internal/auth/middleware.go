@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+)
+
+// Authenticate puts the user of a Bearer token in the request context. The
+// token is either an access token or an API key; for API keys the key is put
+// in the context as well so RequireScope can check it, and for access tokens
+// the ID of their session. Requests without an Authorization header pass
+// through anonymously; use RequireUser on routes that need a user.
+func Authenticate(service AuthService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme, token, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				common.InvalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			var u *user.User
+			var key *APIKey
+			var sessionID string
+			var err error
+
+			if strings.HasPrefix(token, APIKeyPrefix) {
+				u, key, err = service.AuthenticateAPIKey(token)
+			} else {
+				u, sessionID, err = service.Authenticate(token)
+			}
+
+			if err != nil {
+				switch {
+				case errors.Is(err, ErrInvalidToken):
+					common.InvalidAuthenticationTokenResponse(w, r)
+				default:
+					common.ServerErrorResponse(w, r, err)
+				}
+				return
+			}
+
+			r = ContextSetUser(r, u)
+			if key != nil {
+				r = ContextSetAPIKey(r, key)
+			}
+			if sessionID != "" {
+				r = ContextSetSessionID(r, sessionID)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireUser rejects requests that were not authenticated by Authenticate.
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := ContextGetUser(r); !ok {
+			common.AuthenticationRequiredResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope rejects requests authenticated with an API key that does not
+// grant scope. Requests authenticated as a user, and anonymous requests, are
+// not restricted by scopes.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := ContextGetAPIKey(r); ok && !key.HasScope(scope) {
+				common.NotPermittedResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission rejects anonymous requests with 401 and requests from
+// users whose role does not grant permission with 403. Users whose role
+// requires two-factor authentication are rejected with 403 until they enable
+// it.
+func RequirePermission(permission Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u, ok := ContextGetUser(r)
+			if !ok {
+				common.AuthenticationRequiredResponse(w, r)
+				return
+			}
+
+			if !HasPermission(u.Role, permission) {
+				common.NotPermittedResponse(w, r)
+				return
+			}
+
+			if user.RequiresTwoFactor(u.Role) && !u.TwoFactorEnabled() {
+				common.TwoFactorRequiredResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
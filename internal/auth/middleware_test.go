@@ -0,0 +1,172 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticateMiddleware(t *testing.T) {
+	mockService := new(MockAuthService)
+
+	u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+	sessionID := uuid.NewString()
+
+	mockService.On("Authenticate", "valid").Return(u, sessionID, nil)
+	mockService.On("Authenticate", "invalid").Return((*user.User)(nil), "", ErrInvalidToken)
+
+	var got *user.User
+	var gotSessionID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ContextGetUser(r)
+		gotSessionID, _ = ContextGetSessionID(r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	handler := Authenticate(mockService)(RequireUser(next))
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		user          *user.User
+	}{
+		{"Valid token", "Bearer valid", http.StatusNoContent, u},
+		{"Scheme is case insensitive", "bearer valid", http.StatusNoContent, u},
+		{"Invalid token", "Bearer invalid", http.StatusUnauthorized, nil},
+		{"Other scheme", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, nil},
+		{"Missing header", "", http.StatusUnauthorized, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run("Authenticate middleware: "+tt.name, func(t *testing.T) {
+			got = nil
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/api/books", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.user, got)
+			if tt.user != nil {
+				assert.Equal(t, sessionID, gotSessionID)
+			}
+
+			if tt.status == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	t.Run("Authenticate middleware: Anonymous requests pass through", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/api/books", nil)
+		w := httptest.NewRecorder()
+
+		Authenticate(mockService)(next).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	mockService := new(MockAuthService)
+
+	u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com"}
+	readKey := &APIKey{ID: uuid.New(), UserID: u.ID, Scopes: []string{ScopeBooksRead}}
+
+	mockService.On("AuthenticateAPIKey", "gbr_read").Return(u, readKey, nil)
+	mockService.On("AuthenticateAPIKey", "gbr_revoked").Return((*user.User)(nil), (*APIKey)(nil), ErrInvalidToken)
+	mockService.On("Authenticate", "access").Return(u, "", nil)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		scope         string
+		status        int
+	}{
+		{"Key with the scope", "Bearer gbr_read", ScopeBooksRead, http.StatusNoContent},
+		{"Key without the scope", "Bearer gbr_read", ScopeBooksWrite, http.StatusForbidden},
+		{"Revoked key", "Bearer gbr_revoked", ScopeBooksRead, http.StatusUnauthorized},
+		{"Access tokens are not limited by scopes", "Bearer access", ScopeAdmin, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run("API key middleware: "+tt.name, func(t *testing.T) {
+			handler := Authenticate(mockService)(RequireUser(RequireScope(tt.scope)(next)))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/api/books", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestRequirePermissionMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	handler := RequirePermission(PermissionBooksDelete)(next)
+
+	enabledAt := time.Now()
+
+	tests := []struct {
+		name   string
+		user   *user.User
+		status int
+	}{
+		{"Admin", &user.User{ID: uuid.New(), Role: user.RoleAdmin, TwoFactorEnabledAt: &enabledAt}, http.StatusNoContent},
+		{"Moderator", &user.User{ID: uuid.New(), Role: user.RoleModerator, TwoFactorEnabledAt: &enabledAt}, http.StatusNoContent},
+		{"Admin without two-factor authentication", &user.User{ID: uuid.New(), Role: user.RoleAdmin}, http.StatusForbidden},
+		{"Moderator without two-factor authentication", &user.User{ID: uuid.New(), Role: user.RoleModerator}, http.StatusForbidden},
+		{"Editor", &user.User{ID: uuid.New(), Role: user.RoleEditor}, http.StatusForbidden},
+		{"Reader", &user.User{ID: uuid.New(), Role: user.RoleReader}, http.StatusForbidden},
+		{"Anonymous", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run("RequirePermission middleware: "+tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/v1/api/books/"+uuid.NewString(), nil)
+			if tt.user != nil {
+				req = ContextSetUser(req, tt.user)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	assert.True(t, HasPermission(user.RoleReader, PermissionSuggestionsSubmit))
+	assert.False(t, HasPermission(user.RoleReader, PermissionBooksWrite))
+	assert.True(t, HasPermission(user.RoleEditor, PermissionBooksWrite))
+	assert.False(t, HasPermission(user.RoleEditor, PermissionSuggestionsReview))
+	assert.True(t, HasPermission(user.RoleModerator, PermissionSuggestionsReview))
+	assert.False(t, HasPermission(user.RoleModerator, PermissionUsersManage))
+	assert.True(t, HasPermission(user.RoleAdmin, PermissionUsersManage))
+	assert.False(t, HasPermission("owner", PermissionSuggestionsSubmit))
+}
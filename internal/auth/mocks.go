@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/oidc"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+type MockIdentityProvider struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Save(token *RefreshToken) (*RefreshToken, error) {
+	args := m.Called(token)
+	return args.Get(0).(*RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) FindByHash(hash []byte) (*RefreshToken, error) {
+	args := m.Called(hash)
+	return args.Get(0).(*RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Revoke(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyID string) error {
+	args := m.Called(familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeByUser(userID string) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Save(session *Session) (*Session, error) {
+	args := m.Called(session)
+	return args.Get(0).(*Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindByUser(userID string, activeSince time.Time) ([]Session, error) {
+	args := m.Called(userID, activeSince)
+	return args.Get(0).([]Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindRevokedSince(since time.Time) ([]string, error) {
+	args := m.Called(since)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSessionRepository) Touch(id string, client *Client) error {
+	args := m.Called(id, client)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Revoke(userID, id string) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeByUser(userID string) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) Login(req *user.LoginRequest, client *Client) (*Tokens, error) {
+	args := m.Called(req, client)
+	return args.Get(0).(*Tokens), args.Error(1)
+}
+
+func (m *MockAuthService) Refresh(refreshToken string, client *Client) (*Tokens, error) {
+	args := m.Called(refreshToken, client)
+	return args.Get(0).(*Tokens), args.Error(1)
+}
+
+func (m *MockAuthService) Logout(refreshToken string) error {
+	args := m.Called(refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) Authenticate(accessToken string) (*user.User, string, error) {
+	args := m.Called(accessToken)
+	return args.Get(0).(*user.User), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthService) ListSessions(userID string) ([]Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]Session), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(userID, id string) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) CreateAPIKey(userID string, req *CreateAPIKeyRequest) (*APIKey, string, error) {
+	args := m.Called(userID, req)
+	return args.Get(0).(*APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthService) ListAPIKeys(userID string) ([]APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]APIKey), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeAPIKey(userID, id string) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) AuthenticateAPIKey(key string) (*user.User, *APIKey, error) {
+	args := m.Called(key)
+	return args.Get(0).(*user.User), args.Get(1).(*APIKey), args.Error(2)
+}
+
+func (m *MockAPIKeyRepository) Save(key *APIKey) (*APIKey, error) {
+	args := m.Called(key)
+	return args.Get(0).(*APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindByHash(hash []byte) (*APIKey, error) {
+	args := m.Called(hash)
+	return args.Get(0).(*APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindByUser(userID string) ([]APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(userID, id string) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Touch(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) OIDCLogin() (string, string, error) {
+	args := m.Called()
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthService) OIDCLink(userID string) (string, string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthService) OIDCCallback(flow, state, code string, client *Client) (*Tokens, error) {
+	args := m.Called(flow, state, code, client)
+	return args.Get(0).(*Tokens), args.Error(1)
+}
+
+func (m *MockIdentityProvider) Issuer() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockIdentityProvider) AuthCodeURL(state, nonce, challenge string) string {
+	args := m.Called(state, nonce, challenge)
+	return args.String(0)
+}
+
+func (m *MockIdentityProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.IDToken, error) {
+	args := m.Called(ctx, code, verifier, nonce)
+	return args.Get(0).(*oidc.IDToken), args.Error(1)
+}
+
+func (m *MockAuthService) ResetPassword(req *user.ResetPasswordRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LoginTwoFactor(req *TwoFactorLoginRequest, client *Client) (*Tokens, error) {
+	args := m.Called(req, client)
+	return args.Get(0).(*Tokens), args.Error(1)
+}
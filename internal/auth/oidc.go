@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/jwt"
+	"github.com/jakottelaar/gobookreviewapp/pkg/oidc"
+)
+
+// oidcFlowTTL is how long a user has to log in at the identity provider.
+const oidcFlowTTL = 10 * time.Minute
+
+var (
+	ErrOIDCNotConfigured = errors.New("logging in with an identity provider is not configured")
+	ErrInvalidOIDCState  = errors.New("the login was not started here or has expired")
+	ErrOIDCLoginFailed   = errors.New("the identity provider did not confirm the login")
+)
+
+// IdentityProvider is an OpenID Provider users can log in with.
+type IdentityProvider interface {
+	Issuer() string
+	AuthCodeURL(state, nonce, challenge string) string
+	Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.IDToken, error)
+}
+
+// oidcFlowClaims carry the secrets of a login at the identity provider from
+// its start to the callback. They are signed like access tokens and kept in
+// a cookie, which ties the callback to the browser that started the login.
+// The subject is set when a logged in user links an account instead.
+type oidcFlowClaims struct {
+	tokenClaims
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// OIDCLogin starts a login at the identity provider. It returns the URL to
+// send the user to and the flow token to hand to OIDCCallback.
+func (s *authService) OIDCLogin() (string, string, error) {
+	return s.startOIDCFlow("")
+}
+
+// OIDCLink starts linking an account at the identity provider to a logged in
+// user. It continues at OIDCCallback like a login.
+func (s *authService) OIDCLink(userID string) (string, string, error) {
+	return s.startOIDCFlow(userID)
+}
+
+func (s *authService) startOIDCFlow(userID string) (string, string, error) {
+	if s.provider == nil {
+		return "", "", ErrOIDCNotConfigured
+	}
+
+	state, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+
+	flow, err := jwt.Sign(oidcFlowClaims{
+		tokenClaims: tokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   userID,
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(oidcFlowTTL).Unix(),
+			},
+			Type: tokenTypeOIDCFlow,
+		},
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+	}, s.config.Secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return s.provider.AuthCodeURL(state, nonce, oidc.Challenge(verifier)), flow, nil
+}
+
+// OIDCCallback completes a login at the identity provider. The state must
+// match the one in the flow token. The user is provisioned or linked on the
+// first login, or the account is linked to the user who started the flow,
+// after which the login continues as a password login would.
+func (s *authService) OIDCCallback(flow, state, code string, client *Client) (*Tokens, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	var claims oidcFlowClaims
+
+	err := parseToken(flow, s.config.Secret, tokenTypeOIDCFlow, &claims)
+	if err != nil || claims.State == "" || subtle.ConstantTimeCompare([]byte(claims.State), []byte(state)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	idToken, err := s.provider.Exchange(ctx, code, claims.Verifier, claims.Nonce)
+
+	if err != nil {
+		var tokenErr *oidc.TokenError
+
+		switch {
+		case errors.Is(err, oidc.ErrInvalidIDToken), errors.As(err, &tokenErr):
+			return nil, ErrOIDCLoginFailed
+		default:
+			return nil, err
+		}
+	}
+
+	external := &user.ExternalUser{
+		Issuer:        s.provider.Issuer(),
+		Subject:       idToken.Subject,
+		Email:         idToken.Email,
+		EmailVerified: idToken.EmailVerified,
+		Name:          idToken.Name,
+	}
+
+	var u *user.User
+
+	if claims.Subject != "" {
+		u, err = s.users.LinkExternal(claims.Subject, external)
+	} else {
+		u, err = s.users.LoginExternal(external)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(u, client)
+}
@@ -0,0 +1,169 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/jwt"
+	"github.com/jakottelaar/gobookreviewapp/pkg/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testIssuer = "https://sso.example.com"
+
+// startOIDCLogin starts a login and returns the flow token and the state and
+// nonce passed to the provider.
+func startOIDCLogin(t *testing.T, service AuthService, provider *MockIdentityProvider) (string, string, string) {
+	var state, nonce string
+
+	provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		state, nonce = args.String(0), args.String(1)
+	}).Return(testIssuer + "/authorize").Once()
+
+	authURL, flow, err := service.OIDCLogin()
+
+	require.NoError(t, err)
+	assert.Equal(t, testIssuer+"/authorize", authURL)
+
+	return flow, state, nonce
+}
+
+func TestOIDCLoginAuthService(t *testing.T) {
+	t.Run("OIDC login auth service: Flow token carries the PKCE verifier", func(t *testing.T) {
+		provider := new(MockIdentityProvider)
+		service := NewAuthService(new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockAPIKeyRepository), new(user.MockUserService), provider, testConfig)
+
+		var challenge string
+		provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			challenge = args.String(2)
+		}).Return(testIssuer + "/authorize").Once()
+
+		_, flow, err := service.OIDCLogin()
+		require.NoError(t, err)
+
+		var claims oidcFlowClaims
+		require.NoError(t, jwt.Parse(flow, testConfig.Secret, &claims))
+
+		assert.Equal(t, oidc.Challenge(claims.Verifier), challenge)
+		assert.NotEqual(t, claims.State, claims.Nonce)
+	})
+
+	t.Run("OIDC login auth service: Not configured", func(t *testing.T) {
+		service := NewAuthService(new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+		_, _, err := service.OIDCLogin()
+		assert.ErrorIs(t, err, ErrOIDCNotConfigured)
+
+		_, err = service.OIDCCallback("flow", "state", "code", testClient)
+		assert.ErrorIs(t, err, ErrOIDCNotConfigured)
+	})
+}
+
+func TestOIDCCallbackAuthService(t *testing.T) {
+	t.Run("OIDC callback auth service: Issue tokens", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		mockUsers := new(user.MockUserService)
+		provider := new(MockIdentityProvider)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), mockUsers, provider, testConfig)
+
+		flow, state, nonce := startOIDCLogin(t, service, provider)
+
+		idToken := &oidc.IDToken{RegisteredClaims: jwt.RegisteredClaims{Subject: "42"}, Email: "jane.doe@example.com", EmailVerified: true, Name: "Jane Doe"}
+		u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+		provider.On("Issuer").Return(testIssuer)
+		provider.On("Exchange", mock.Anything, "code", mock.AnythingOfType("string"), nonce).Return(idToken, nil).Once()
+		mockUsers.On("LoginExternal", &user.ExternalUser{
+			Issuer:        testIssuer,
+			Subject:       "42",
+			Email:         "jane.doe@example.com",
+			EmailVerified: true,
+			Name:          "Jane Doe",
+		}).Return(u, nil).Once()
+		mockSessions.On("Save", mock.MatchedBy(func(session *Session) bool {
+			return session.UserID == u.ID && session.IPAddress == testClient.IPAddress
+		})).Return(&Session{ID: uuid.New(), UserID: u.ID}, nil).Once()
+		mockRepo.On("Save", mock.MatchedBy(func(token *RefreshToken) bool {
+			return token.UserID == u.ID
+		})).Return(&RefreshToken{}, nil).Once()
+
+		tokens, err := service.OIDCCallback(flow, state, "code", testClient)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+
+		provider.AssertExpectations(t)
+		mockUsers.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("OIDC callback auth service: Link to the user who started the flow", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		mockUsers := new(user.MockUserService)
+		provider := new(MockIdentityProvider)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), mockUsers, provider, testConfig)
+
+		u := &user.User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+		var nonce string
+		provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			nonce = args.String(1)
+		}).Return(testIssuer + "/authorize").Once()
+
+		_, flow, err := service.OIDCLink(u.ID.String())
+		require.NoError(t, err)
+
+		var claims oidcFlowClaims
+		require.NoError(t, jwt.Parse(flow, testConfig.Secret, &claims))
+
+		provider.On("Issuer").Return(testIssuer)
+		provider.On("Exchange", mock.Anything, "code", mock.AnythingOfType("string"), nonce).Return(&oidc.IDToken{RegisteredClaims: jwt.RegisteredClaims{Subject: "42"}, Email: "jane@example.org"}, nil).Once()
+		mockUsers.On("LinkExternal", u.ID.String(), mock.MatchedBy(func(external *user.ExternalUser) bool {
+			return external.Issuer == testIssuer && external.Subject == "42"
+		})).Return(u, nil).Once()
+		mockSessions.On("Save", mock.Anything).Return(&Session{ID: uuid.New(), UserID: u.ID}, nil).Once()
+		mockRepo.On("Save", mock.Anything).Return(&RefreshToken{}, nil).Once()
+
+		_, err = service.OIDCCallback(flow, claims.State, "code", testClient)
+
+		require.NoError(t, err)
+		mockUsers.AssertExpectations(t)
+		mockUsers.AssertNotCalled(t, "LoginExternal", mock.Anything)
+	})
+
+	t.Run("OIDC callback auth service: State does not match", func(t *testing.T) {
+		provider := new(MockIdentityProvider)
+		service := NewAuthService(new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockAPIKeyRepository), new(user.MockUserService), provider, testConfig)
+
+		flow, _, _ := startOIDCLogin(t, service, provider)
+
+		_, err := service.OIDCCallback(flow, "another state", "code", testClient)
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+
+		_, err = service.OIDCCallback("not a flow token", "", "code", testClient)
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+
+		provider.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("OIDC callback auth service: Provider rejects the code", func(t *testing.T) {
+		provider := new(MockIdentityProvider)
+		service := NewAuthService(new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockAPIKeyRepository), new(user.MockUserService), provider, testConfig)
+
+		flow, state, _ := startOIDCLogin(t, service, provider)
+
+		provider.On("Exchange", mock.Anything, "code", mock.Anything, mock.Anything).Return((*oidc.IDToken)(nil), &oidc.TokenError{Code: "invalid_grant"}).Once()
+
+		_, err := service.OIDCCallback(flow, state, "code", testClient)
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+	})
+}
@@ -0,0 +1,50 @@
+package auth
+
+import "github.com/jakottelaar/gobookreviewapp/internal/user"
+
+// Permission is an action on the API that only some roles may perform.
+type Permission string
+
+const (
+	PermissionBooksWrite        Permission = "books:write"
+	PermissionBooksDelete       Permission = "books:delete"
+	PermissionSuggestionsSubmit Permission = "suggestions:submit"
+	PermissionSuggestionsReview Permission = "suggestions:review"
+	PermissionUsersManage       Permission = "users:manage"
+)
+
+// rolePermissions is the permission matrix. Every role also has the
+// permissions of the roles below it.
+var rolePermissions = map[string][]Permission{
+	user.RoleReader: {
+		PermissionSuggestionsSubmit,
+	},
+	user.RoleEditor: {
+		PermissionSuggestionsSubmit,
+		PermissionBooksWrite,
+	},
+	user.RoleModerator: {
+		PermissionSuggestionsSubmit,
+		PermissionBooksWrite,
+		PermissionBooksDelete,
+		PermissionSuggestionsReview,
+	},
+	user.RoleAdmin: {
+		PermissionSuggestionsSubmit,
+		PermissionBooksWrite,
+		PermissionBooksDelete,
+		PermissionSuggestionsReview,
+		PermissionUsersManage,
+	},
+}
+
+// HasPermission reports whether role grants permission. Unknown roles have no
+// permissions.
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
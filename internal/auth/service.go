@@ -0,0 +1,344 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/jwt"
+)
+
+type AuthService interface {
+	Login(req *user.LoginRequest, client *Client) (*Tokens, error)
+	LoginTwoFactor(req *TwoFactorLoginRequest, client *Client) (*Tokens, error)
+	Refresh(refreshToken string, client *Client) (*Tokens, error)
+	Logout(refreshToken string) error
+	Authenticate(accessToken string) (*user.User, string, error)
+	ListSessions(userID string) ([]Session, error)
+	RevokeSession(userID, id string) error
+	CreateAPIKey(userID string, req *CreateAPIKeyRequest) (*APIKey, string, error)
+	ListAPIKeys(userID string) ([]APIKey, error)
+	RevokeAPIKey(userID, id string) error
+	AuthenticateAPIKey(key string) (*user.User, *APIKey, error)
+	OIDCLogin() (string, string, error)
+	OIDCLink(userID string) (string, string, error)
+	OIDCCallback(flow, state, code string, client *Client) (*Tokens, error)
+	ResetPassword(req *user.ResetPasswordRequest) error
+}
+
+type authService struct {
+	repo     RefreshTokenRepository
+	sessions SessionRepository
+	keys     APIKeyRepository
+	users    user.UserService
+	provider IdentityProvider
+	config   TokenConfig
+	denylist *denylist
+}
+
+// NewAuthService returns an AuthService. provider may be nil if logging in
+// with an identity provider is not configured.
+func NewAuthService(repo RefreshTokenRepository, sessions SessionRepository, keys APIKeyRepository, users user.UserService, provider IdentityProvider, config TokenConfig) AuthService {
+	return &authService{
+		repo:     repo,
+		sessions: sessions,
+		keys:     keys,
+		users:    users,
+		provider: provider,
+		config:   config,
+		denylist: newDenylist(sessions, config.AccessTokenTTL),
+	}
+}
+
+// Login checks the credentials of a user and starts a new session on the
+// client. Users with two-factor authentication enabled get a
+// *TwoFactorChallenge error instead.
+func (s *authService) Login(req *user.LoginRequest, client *Client) (*Tokens, error) {
+	u, err := s.users.Login(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(u, client)
+}
+
+// Refresh rotates a refresh token: the token is revoked and a new access and
+// refresh token are issued. Presenting a token that was already revoked means
+// it has leaked, so its whole session is revoked as well.
+func (s *authService) Refresh(refreshToken string, client *Client) (*Tokens, error) {
+	stored, err := s.repo.FindByHash(hashToken(refreshToken))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return nil, ErrInvalidToken
+		default:
+			return nil, err
+		}
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, s.revokeFamily(stored)
+	}
+
+	if !time.Now().Before(stored.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	err = s.repo.Revoke(stored.ID.String())
+
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTokenRevoked):
+			return nil, s.revokeFamily(stored)
+		default:
+			return nil, err
+		}
+	}
+
+	err = s.sessions.Touch(stored.FamilyID.String(), client)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(stored.UserID, stored.FamilyID)
+}
+
+// Logout revokes the session of the given refresh token. Unknown tokens are
+// ignored so logging out twice is not an error.
+func (s *authService) Logout(refreshToken string) error {
+	stored, err := s.repo.FindByHash(hashToken(refreshToken))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	return s.endSession(stored)
+}
+
+// ResetPassword sets a new password with a reset token and revokes every
+// session of the user, so whoever knew the old password is logged out.
+func (s *authService) ResetPassword(req *user.ResetPasswordRequest) error {
+	u, err := s.users.ResetPassword(req)
+	if err != nil {
+		return err
+	}
+
+	err = s.sessions.RevokeByUser(u.ID.String())
+	if err != nil {
+		return err
+	}
+
+	s.denylist.Expire()
+
+	return s.repo.RevokeByUser(u.ID.String())
+}
+
+// Authenticate returns the user an access token was issued to and the ID of
+// its session. Access tokens of revoked sessions are rejected.
+func (s *authService) Authenticate(accessToken string) (*user.User, string, error) {
+	var claims AccessClaims
+
+	err := parseToken(accessToken, s.config.Secret, tokenTypeAccess, &claims)
+	if err != nil || claims.Subject == "" {
+		return nil, "", ErrInvalidToken
+	}
+
+	if claims.SessionID != "" {
+		revoked, err := s.denylist.Contains(claims.SessionID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if revoked {
+			return nil, "", ErrInvalidToken
+		}
+	}
+
+	u, err := s.users.GetUserById(claims.Subject)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return nil, "", ErrInvalidToken
+		default:
+			return nil, "", err
+		}
+	}
+
+	return u, claims.SessionID, nil
+}
+
+// CreateAPIKey creates a key for the user. The key itself is returned only
+// here; afterwards just its prefix is known.
+func (s *authService) CreateAPIKey(userID string, req *CreateAPIKeyRequest) (*APIKey, string, error) {
+	secret, err := generateToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	plain := APIKeyPrefix + secret
+
+	key := &APIKey{
+		ID:        uuid.New(),
+		UserID:    uuid.MustParse(userID),
+		Name:      req.Name,
+		Prefix:    plain[:len(APIKeyPrefix)+8],
+		KeyHash:   hashToken(plain),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	key, err = s.keys.Save(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, plain, nil
+}
+
+func (s *authService) ListAPIKeys(userID string) ([]APIKey, error) {
+	return s.keys.FindByUser(userID)
+}
+
+func (s *authService) RevokeAPIKey(userID, id string) error {
+	return s.keys.Revoke(userID, id)
+}
+
+// AuthenticateAPIKey returns the key and the user it acts for. Revoked and
+// expired keys are rejected.
+func (s *authService) AuthenticateAPIKey(plain string) (*user.User, *APIKey, error) {
+	key, err := s.keys.FindByHash(hashToken(plain))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return nil, nil, ErrInvalidToken
+		default:
+			return nil, nil, err
+		}
+	}
+
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !time.Now().Before(*key.ExpiresAt)) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	u, err := s.users.GetUserById(key.UserID.String())
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return nil, nil, ErrInvalidToken
+		default:
+			return nil, nil, err
+		}
+	}
+
+	err = s.keys.Touch(key.ID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u, key, nil
+}
+
+// startSession saves a new session for the client and issues its first
+// tokens.
+func (s *authService) startSession(userID uuid.UUID, client *Client) (*Tokens, error) {
+	session, err := s.sessions.Save(&Session{
+		ID:        uuid.New(),
+		UserID:    userID,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(userID, session.ID)
+}
+
+// issue issues tokens for a session. The refresh token joins the family of
+// the session.
+func (s *authService) issue(userID, familyID uuid.UUID) (*Tokens, error) {
+	now := time.Now()
+
+	tokens := &Tokens{
+		AccessTokenExpiresAt:  now.Add(s.config.AccessTokenTTL),
+		RefreshTokenExpiresAt: now.Add(s.config.RefreshTokenTTL),
+	}
+
+	claims := AccessClaims{
+		tokenClaims: tokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   userID.String(),
+				ID:        uuid.NewString(),
+				IssuedAt:  now.Unix(),
+				ExpiresAt: tokens.AccessTokenExpiresAt.Unix(),
+			},
+			Type: tokenTypeAccess,
+		},
+		SessionID: familyID.String(),
+	}
+
+	var err error
+
+	tokens.AccessToken, err = jwt.Sign(claims, s.config.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens.RefreshToken, err = generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.repo.Save(&RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(tokens.RefreshToken),
+		ExpiresAt: tokens.RefreshTokenExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *authService) revokeFamily(stored *RefreshToken) error {
+	err := s.endSession(stored)
+	if err != nil {
+		return err
+	}
+
+	return ErrInvalidToken
+}
+
+// generateToken returns 32 random bytes encoded for use in URLs and headers.
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
@@ -0,0 +1,379 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = TokenConfig{
+	Secret:          []byte("0123456789abcdef0123456789abcdef"),
+	AccessTokenTTL:  15 * time.Minute,
+	RefreshTokenTTL: time.Hour,
+}
+
+var testClient = &Client{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0", IPAddress: "203.0.113.7"}
+
+func TestLoginAuthService(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	mockSessions := new(MockSessionRepository)
+	mockUsers := new(user.MockUserService)
+	service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), mockUsers, nil, testConfig)
+
+	t.Run("Login auth service: Issue tokens", func(t *testing.T) {
+		req := &user.LoginRequest{Email: "jane.doe@example.com", Password: "correct-Horse-battery-5taple"}
+		u := &user.User{ID: uuid.New(), Email: req.Email}
+
+		mockUsers.On("Login", req).Return(u, nil).Once()
+
+		session := &Session{ID: uuid.New(), UserID: u.ID}
+		mockSessions.On("Save", mock.MatchedBy(func(s *Session) bool {
+			return s.UserID == u.ID && s.UserAgent == testClient.UserAgent && s.IPAddress == testClient.IPAddress
+		})).Return(session, nil).Once()
+
+		var saved *RefreshToken
+		mockRepo.On("Save", mock.AnythingOfType("*auth.RefreshToken")).Run(func(args mock.Arguments) {
+			saved = args.Get(0).(*RefreshToken)
+		}).Return(&RefreshToken{}, nil).Once()
+
+		tokens, err := service.Login(req, testClient)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+
+		require.NotNil(t, saved)
+		assert.Equal(t, u.ID, saved.UserID)
+		assert.Equal(t, session.ID, saved.FamilyID)
+		assert.Equal(t, hashToken(tokens.RefreshToken), saved.TokenHash)
+		assert.NotContains(t, string(saved.TokenHash), tokens.RefreshToken)
+
+		mockUsers.On("GetUserById", u.ID.String()).Return(u, nil).Once()
+		mockSessions.On("FindRevokedSince", mock.AnythingOfType("time.Time")).Return([]string{}, nil).Once()
+
+		authenticated, sessionID, err := service.Authenticate(tokens.AccessToken)
+
+		require.NoError(t, err)
+		assert.Equal(t, u, authenticated)
+		assert.Equal(t, session.ID.String(), sessionID)
+
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("Login auth service: Invalid credentials", func(t *testing.T) {
+		req := &user.LoginRequest{Email: "jane.doe@example.com", Password: "wrong"}
+
+		mockUsers.On("Login", req).Return((*user.User)(nil), user.ErrInvalidCredentials).Once()
+
+		tokens, err := service.Login(req, testClient)
+
+		require.ErrorIs(t, err, user.ErrInvalidCredentials)
+		assert.Nil(t, tokens)
+	})
+}
+
+func TestRefreshAuthService(t *testing.T) {
+	userID := uuid.New()
+	familyID := uuid.New()
+
+	t.Run("Refresh auth service: Rotate the refresh token", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+		stored := &RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}
+
+		mockRepo.On("FindByHash", hashToken("refresh")).Return(stored, nil)
+		mockRepo.On("Revoke", stored.ID.String()).Return(nil)
+		mockRepo.On("Save", mock.MatchedBy(func(token *RefreshToken) bool {
+			return token.FamilyID == familyID && token.UserID == userID
+		})).Return(&RefreshToken{}, nil)
+		mockSessions.On("Touch", familyID.String(), testClient).Return(nil)
+
+		tokens, err := service.Refresh("refresh", testClient)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, "refresh", tokens.RefreshToken)
+
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("Refresh auth service: Reused token revokes the session", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+		revokedAt := time.Now().Add(-time.Minute)
+		stored := &RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}
+
+		mockRepo.On("FindByHash", hashToken("refresh")).Return(stored, nil)
+		mockRepo.On("RevokeFamily", familyID.String()).Return(nil)
+		mockSessions.On("Revoke", userID.String(), familyID.String()).Return(nil)
+
+		tokens, err := service.Refresh("refresh", testClient)
+
+		require.ErrorIs(t, err, ErrInvalidToken)
+		assert.Nil(t, tokens)
+
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("Refresh auth service: Concurrent rotation revokes the session", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+		stored := &RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}
+
+		mockRepo.On("FindByHash", hashToken("refresh")).Return(stored, nil)
+		mockRepo.On("Revoke", stored.ID.String()).Return(ErrTokenRevoked)
+		mockRepo.On("RevokeFamily", familyID.String()).Return(nil)
+		// A session revoked by another request is not found again
+		mockSessions.On("Revoke", userID.String(), familyID.String()).Return(common.ErrNotFound)
+
+		_, err := service.Refresh("refresh", testClient)
+
+		require.ErrorIs(t, err, ErrInvalidToken)
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("Refresh auth service: Expired token", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		service := NewAuthService(mockRepo, new(MockSessionRepository), new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+		stored := &RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(-time.Minute)}
+
+		mockRepo.On("FindByHash", hashToken("refresh")).Return(stored, nil)
+
+		_, err := service.Refresh("refresh", testClient)
+
+		require.ErrorIs(t, err, ErrInvalidToken)
+		mockRepo.AssertNotCalled(t, "Revoke", mock.Anything)
+	})
+
+	t.Run("Refresh auth service: Unknown token", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		service := NewAuthService(mockRepo, new(MockSessionRepository), new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+		mockRepo.On("FindByHash", hashToken("unknown")).Return((*RefreshToken)(nil), common.ErrNotFound)
+
+		_, err := service.Refresh("unknown", testClient)
+
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestLogoutAuthService(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	mockSessions := new(MockSessionRepository)
+	service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+	userID := uuid.New()
+	familyID := uuid.New()
+
+	mockRepo.On("FindByHash", hashToken("refresh")).Return(&RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID}, nil)
+	mockRepo.On("FindByHash", hashToken("unknown")).Return((*RefreshToken)(nil), common.ErrNotFound)
+	mockRepo.On("RevokeFamily", familyID.String()).Return(nil).Once()
+	mockSessions.On("Revoke", userID.String(), familyID.String()).Return(nil).Once()
+
+	require.NoError(t, service.Logout("refresh"))
+	require.NoError(t, service.Logout("unknown"))
+
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestResetPasswordAuthService(t *testing.T) {
+	t.Run("Reset password auth service: Sessions are revoked", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		mockUsers := new(user.MockUserService)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), mockUsers, nil, testConfig)
+
+		u := &user.User{ID: uuid.New()}
+		req := &user.ResetPasswordRequest{Token: "token", Password: "new-Horse-battery-5taple"}
+
+		mockUsers.On("ResetPassword", req).Return(u, nil).Once()
+		mockRepo.On("RevokeByUser", u.ID.String()).Return(nil).Once()
+		mockSessions.On("RevokeByUser", u.ID.String()).Return(nil).Once()
+
+		require.NoError(t, service.ResetPassword(req))
+
+		mockUsers.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("Reset password auth service: Invalid token", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockUsers := new(user.MockUserService)
+		service := NewAuthService(mockRepo, new(MockSessionRepository), new(MockAPIKeyRepository), mockUsers, nil, testConfig)
+
+		req := &user.ResetPasswordRequest{Token: "token", Password: "new-Horse-battery-5taple"}
+
+		mockUsers.On("ResetPassword", req).Return((*user.User)(nil), user.ErrInvalidToken).Once()
+
+		require.ErrorIs(t, service.ResetPassword(req), user.ErrInvalidToken)
+		mockRepo.AssertNotCalled(t, "RevokeByUser", mock.Anything)
+	})
+}
+
+func TestAuthenticateAuthService(t *testing.T) {
+	mockUsers := new(user.MockUserService)
+	mockSessions := new(MockSessionRepository)
+	service := NewAuthService(new(MockRefreshTokenRepository), mockSessions, new(MockAPIKeyRepository), mockUsers, nil, testConfig)
+
+	revokedSessionID := uuid.New()
+	mockSessions.On("FindRevokedSince", mock.AnythingOfType("time.Time")).Return([]string{revokedSessionID.String()}, nil)
+
+	t.Run("Authenticate auth service: Invalid token", func(t *testing.T) {
+		_, _, err := service.Authenticate("not a token")
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Authenticate auth service: Token signed with another secret", func(t *testing.T) {
+		other := NewAuthService(new(MockRefreshTokenRepository), new(MockSessionRepository), new(MockAPIKeyRepository), mockUsers, nil, TokenConfig{Secret: []byte("another secret"), AccessTokenTTL: time.Minute}).(*authService)
+		other.repo.(*MockRefreshTokenRepository).On("Save", mock.Anything).Return(&RefreshToken{}, nil)
+
+		tokens, err := other.issue(uuid.New(), uuid.New())
+		require.NoError(t, err)
+
+		_, _, err = service.Authenticate(tokens.AccessToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Authenticate auth service: Revoked session", func(t *testing.T) {
+		impl := service.(*authService)
+		impl.repo.(*MockRefreshTokenRepository).On("Save", mock.Anything).Return(&RefreshToken{}, nil)
+
+		tokens, err := impl.issue(uuid.New(), revokedSessionID)
+		require.NoError(t, err)
+
+		_, _, err = service.Authenticate(tokens.AccessToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+		mockUsers.AssertNotCalled(t, "GetUserById", mock.Anything)
+	})
+
+	t.Run("Authenticate auth service: Deleted user", func(t *testing.T) {
+		impl := service.(*authService)
+		impl.repo.(*MockRefreshTokenRepository).On("Save", mock.Anything).Return(&RefreshToken{}, nil)
+
+		userID := uuid.New()
+
+		tokens, err := impl.issue(userID, uuid.New())
+		require.NoError(t, err)
+
+		mockUsers.On("GetUserById", userID.String()).Return((*user.User)(nil), common.ErrNotFound).Once()
+
+		_, _, err = service.Authenticate(tokens.AccessToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Authenticate auth service: Other kinds of tokens are rejected", func(t *testing.T) {
+		userID := uuid.New()
+
+		// Even with a subject, a challenge token is not an access token.
+		challenge, err := jwt.Sign(twoFactorClaims{
+			tokenClaims: tokenClaims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String(), ExpiresAt: time.Now().Add(time.Minute).Unix()},
+				Type:             tokenTypeTwoFactorChallenge,
+			},
+			UserID: userID.String(),
+		}, testConfig.Secret)
+		require.NoError(t, err)
+
+		_, _, err = service.Authenticate(challenge)
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		untyped, err := jwt.Sign(jwt.RegisteredClaims{Subject: userID.String(), ExpiresAt: time.Now().Add(time.Minute).Unix()}, testConfig.Secret)
+		require.NoError(t, err)
+
+		_, _, err = service.Authenticate(untyped)
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		mockUsers.AssertNotCalled(t, "GetUserById", userID.String())
+	})
+}
+
+func TestAPIKeyAuthService(t *testing.T) {
+	userID := uuid.New()
+	u := &user.User{ID: userID, Email: "jane.doe@example.com"}
+
+	t.Run("API key auth service: Create and authenticate a key", func(t *testing.T) {
+		mockKeys := new(MockAPIKeyRepository)
+		mockUsers := new(user.MockUserService)
+		service := NewAuthService(new(MockRefreshTokenRepository), new(MockSessionRepository), mockKeys, mockUsers, nil, testConfig)
+
+		var saved *APIKey
+		mockKeys.On("Save", mock.AnythingOfType("*auth.APIKey")).Run(func(args mock.Arguments) {
+			saved = args.Get(0).(*APIKey)
+		}).Return(&APIKey{}, nil).Once()
+
+		req := &CreateAPIKeyRequest{Name: "ingestion", Scopes: []string{ScopeBooksWrite}}
+
+		_, plain, err := service.CreateAPIKey(userID.String(), req)
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(plain, APIKeyPrefix))
+		assert.Equal(t, plain[:12], saved.Prefix)
+		assert.Equal(t, hashToken(plain), saved.KeyHash)
+		assert.Equal(t, userID, saved.UserID)
+
+		mockKeys.On("FindByHash", hashToken(plain)).Return(saved, nil).Once()
+		mockKeys.On("Touch", saved.ID.String()).Return(nil).Once()
+		mockUsers.On("GetUserById", userID.String()).Return(u, nil).Once()
+
+		authenticated, authenticatedKey, err := service.AuthenticateAPIKey(plain)
+
+		require.NoError(t, err)
+		assert.Equal(t, u, authenticated)
+		assert.Equal(t, saved, authenticatedKey)
+
+		mockKeys.AssertExpectations(t)
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("API key auth service: Reject revoked and expired keys", func(t *testing.T) {
+		mockKeys := new(MockAPIKeyRepository)
+		service := NewAuthService(new(MockRefreshTokenRepository), new(MockSessionRepository), mockKeys, new(user.MockUserService), nil, testConfig)
+
+		past := time.Now().Add(-time.Minute)
+
+		mockKeys.On("FindByHash", hashToken("gbr_revoked")).Return(&APIKey{ID: uuid.New(), UserID: userID, RevokedAt: &past}, nil)
+		mockKeys.On("FindByHash", hashToken("gbr_expired")).Return(&APIKey{ID: uuid.New(), UserID: userID, ExpiresAt: &past}, nil)
+		mockKeys.On("FindByHash", hashToken("gbr_unknown")).Return((*APIKey)(nil), common.ErrNotFound)
+
+		for _, key := range []string{"gbr_revoked", "gbr_expired", "gbr_unknown"} {
+			_, _, err := service.AuthenticateAPIKey(key)
+			assert.ErrorIs(t, err, ErrInvalidToken, key)
+		}
+
+		mockKeys.AssertNotCalled(t, "Touch", mock.Anything)
+	})
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	key := &APIKey{Scopes: []string{ScopeBooksRead}}
+	assert.True(t, key.HasScope(ScopeBooksRead))
+	assert.False(t, key.HasScope(ScopeBooksWrite))
+
+	admin := &APIKey{Scopes: []string{ScopeAdmin}}
+	assert.True(t, admin.HasScope(ScopeBooksWrite))
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+)
+
+// denylistRefresh is how often the denylist is reloaded. It bounds how long a
+// session revoked by another instance of the API keeps working.
+const denylistRefresh = 30 * time.Second
+
+// denylist caches the IDs of recently revoked sessions, so access tokens of
+// revoked sessions can be rejected without a query per request. Only
+// sessions revoked within the lifetime of an access token are kept; every
+// access token of an older revoked session has expired anyway.
+type denylist struct {
+	sessions SessionRepository
+	ttl      time.Duration
+
+	mu       sync.Mutex
+	revoked  map[string]struct{}
+	loadedAt time.Time
+}
+
+func newDenylist(sessions SessionRepository, ttl time.Duration) *denylist {
+	return &denylist{
+		sessions: sessions,
+		ttl:      ttl,
+		revoked:  map[string]struct{}{},
+	}
+}
+
+// Contains reports whether a session was revoked, reloading the list first if
+// it is stale.
+func (d *denylist) Contains(id string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(d.loadedAt) >= denylistRefresh {
+		// Look back a little further than the token lifetime to allow for
+		// clock differences with the database.
+		ids, err := d.sessions.FindRevokedSince(now.Add(-d.ttl - denylistRefresh))
+		if err != nil {
+			return false, err
+		}
+
+		d.revoked = make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			d.revoked[id] = struct{}{}
+		}
+		d.loadedAt = now
+	}
+
+	_, ok := d.revoked[id]
+
+	return ok, nil
+}
+
+// Add denies a session revoked by this instance right away.
+func (d *denylist) Add(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.revoked[id] = struct{}{}
+}
+
+// Expire makes the next lookup reload the list, for when sessions were
+// revoked without knowing their IDs.
+func (d *denylist) Expire() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.loadedAt = time.Time{}
+}
+
+// ListSessions returns the active sessions of a user. A session is active
+// until it is revoked or has not been refreshed for the lifetime of a refresh
+// token.
+func (s *authService) ListSessions(userID string) ([]Session, error) {
+	return s.sessions.FindByUser(userID, time.Now().Add(-s.config.RefreshTokenTTL))
+}
+
+// RevokeSession logs a user out on one device. The refresh tokens of the
+// session stop working and its access tokens are rejected from now on.
+func (s *authService) RevokeSession(userID, id string) error {
+	err := s.sessions.Revoke(userID, id)
+	if err != nil {
+		return err
+	}
+
+	s.denylist.Add(id)
+
+	return s.repo.RevokeFamily(id)
+}
+
+// endSession revokes a session that a refresh token of was used, whether or
+// not the session was already revoked.
+func (s *authService) endSession(stored *RefreshToken) error {
+	err := s.sessions.Revoke(stored.UserID.String(), stored.FamilyID.String())
+	if err != nil && !errors.Is(err, common.ErrNotFound) {
+		return err
+	}
+
+	s.denylist.Add(stored.FamilyID.String())
+
+	return s.repo.RevokeFamily(stored.FamilyID.String())
+}
+
+// newClient describes the device a request came from.
+func newClient(r *http.Request) *Client {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	return &Client{
+		UserAgent: userAgent,
+		IPAddress: ip,
+	}
+}
+
+var (
+	// browsers are checked in order, as most user agents mention the
+	// browsers they are compatible with as well.
+	browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+		{"Go-http-client/", "Go"},
+	}
+	systems = []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// describeDevice returns a short description of a user agent, such as
+// "Firefox on Linux", for users to recognise their sessions by.
+func describeDevice(userAgent string) string {
+	var browser, system string
+
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Unknown device"
+	}
+}
@@ -0,0 +1,158 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDenylist(t *testing.T) {
+	t.Run("Denylist: Loaded once per refresh interval", func(t *testing.T) {
+		mockSessions := new(MockSessionRepository)
+		list := newDenylist(mockSessions, 15*time.Minute)
+
+		mockSessions.On("FindRevokedSince", mock.MatchedBy(func(since time.Time) bool {
+			return since.Before(time.Now().Add(-15 * time.Minute))
+		})).Return([]string{"revoked"}, nil)
+
+		revoked, err := list.Contains("revoked")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = list.Contains("active")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		mockSessions.AssertNumberOfCalls(t, "FindRevokedSince", 1)
+	})
+
+	t.Run("Denylist: Added sessions are denied right away", func(t *testing.T) {
+		mockSessions := new(MockSessionRepository)
+		list := newDenylist(mockSessions, 15*time.Minute)
+
+		mockSessions.On("FindRevokedSince", mock.Anything).Return([]string{}, nil)
+
+		revoked, err := list.Contains("session")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		list.Add("session")
+
+		revoked, err = list.Contains("session")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("Denylist: Expired list is reloaded", func(t *testing.T) {
+		mockSessions := new(MockSessionRepository)
+		list := newDenylist(mockSessions, 15*time.Minute)
+
+		mockSessions.On("FindRevokedSince", mock.Anything).Return([]string{}, nil).Once()
+		mockSessions.On("FindRevokedSince", mock.Anything).Return([]string{"session"}, nil).Once()
+
+		revoked, err := list.Contains("session")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		list.Expire()
+
+		revoked, err = list.Contains("session")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		mockSessions.AssertExpectations(t)
+	})
+}
+
+func TestSessionAuthService(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Session auth service: List active sessions", func(t *testing.T) {
+		mockSessions := new(MockSessionRepository)
+		service := NewAuthService(new(MockRefreshTokenRepository), mockSessions, new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+		sessions := []Session{{ID: uuid.New(), UserID: userID}}
+
+		mockSessions.On("FindByUser", userID.String(), mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since).Round(time.Minute) == testConfig.RefreshTokenTTL
+		})).Return(sessions, nil)
+
+		result, err := service.ListSessions(userID.String())
+
+		require.NoError(t, err)
+		assert.Equal(t, sessions, result)
+	})
+
+	t.Run("Session auth service: Revoke a session", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		mockUsers := new(user.MockUserService)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), mockUsers, nil, testConfig)
+
+		mockRepo.On("Save", mock.Anything).Return(&RefreshToken{}, nil)
+		mockSessions.On("FindRevokedSince", mock.Anything).Return([]string{}, nil)
+		mockUsers.On("GetUserById", userID.String()).Return(&user.User{ID: userID}, nil)
+
+		sessionID := uuid.New()
+
+		tokens, err := service.(*authService).issue(userID, sessionID)
+		require.NoError(t, err)
+
+		_, _, err = service.Authenticate(tokens.AccessToken)
+		require.NoError(t, err)
+
+		mockSessions.On("Revoke", userID.String(), sessionID.String()).Return(nil).Once()
+		mockRepo.On("RevokeFamily", sessionID.String()).Return(nil).Once()
+
+		require.NoError(t, service.RevokeSession(userID.String(), sessionID.String()))
+
+		_, _, err = service.Authenticate(tokens.AccessToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("Session auth service: Session of another user", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), new(user.MockUserService), nil, testConfig)
+
+		sessionID := uuid.NewString()
+
+		mockSessions.On("Revoke", userID.String(), sessionID).Return(common.ErrNotFound)
+
+		err := service.RevokeSession(userID.String(), sessionID)
+
+		require.ErrorIs(t, err, common.ErrNotFound)
+		mockRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything)
+	})
+}
+
+func TestDescribeDevice(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		device    string
+	}{
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15", "Safari on macOS"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"curl/8.5.0", "curl"},
+		{"", "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.device, describeDevice(tt.userAgent), tt.userAgent)
+	}
+}
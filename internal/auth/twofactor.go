@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/jwt"
+)
+
+// twoFactorChallengeTTL is how long a user has to enter their second factor
+// after their password.
+const twoFactorChallengeTTL = 5 * time.Minute
+
+var ErrInvalidTwoFactorChallenge = errors.New("the login was not started or has expired")
+
+// TwoFactorChallenge is returned as error by a login of a user with
+// two-factor authentication enabled. The login is completed by passing its
+// token and a code to LoginTwoFactor.
+type TwoFactorChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (c *TwoFactorChallenge) Error() string {
+	return "a second factor is required to complete the login"
+}
+
+// twoFactorClaims are the payload of a challenge token.
+type twoFactorClaims struct {
+	tokenClaims
+	UserID string `json:"uid"`
+}
+
+// completeLogin starts a session for a user who logged in, or returns a
+// challenge if the user has a second factor to enter.
+func (s *authService) completeLogin(u *user.User, client *Client) (*Tokens, error) {
+	if !u.TwoFactorEnabled() {
+		return s.startSession(u.ID, client)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(twoFactorChallengeTTL)
+
+	token, err := jwt.Sign(twoFactorClaims{
+		tokenClaims: tokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        uuid.NewString(),
+				IssuedAt:  now.Unix(),
+				ExpiresAt: expiresAt.Unix(),
+			},
+			Type: tokenTypeTwoFactorChallenge,
+		},
+		UserID: u.ID.String(),
+	}, s.config.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, &TwoFactorChallenge{Token: token, ExpiresAt: expiresAt}
+}
+
+// LoginTwoFactor completes a login with a code from an authenticator app or
+// a recovery code.
+func (s *authService) LoginTwoFactor(req *TwoFactorLoginRequest, client *Client) (*Tokens, error) {
+	var claims twoFactorClaims
+
+	err := parseToken(req.ChallengeToken, s.config.Secret, tokenTypeTwoFactorChallenge, &claims)
+	if err != nil || claims.UserID == "" {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	err = s.users.VerifyTwoFactor(claims.UserID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startSession(userID, client)
+}
@@ -0,0 +1,96 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorAuthService(t *testing.T) {
+	enabledAt := time.Now()
+
+	t.Run("Two-factor auth service: Login returns a challenge", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockSessions := new(MockSessionRepository)
+		mockUsers := new(user.MockUserService)
+		service := NewAuthService(mockRepo, mockSessions, new(MockAPIKeyRepository), mockUsers, nil, testConfig)
+
+		req := &user.LoginRequest{Email: "jane.doe@example.com", Password: "correct-Horse-battery-5taple"}
+		u := &user.User{ID: uuid.New(), Email: req.Email, TwoFactorEnabledAt: &enabledAt}
+
+		mockUsers.On("Login", req).Return(u, nil).Once()
+
+		tokens, err := service.Login(req, testClient)
+
+		var challenge *TwoFactorChallenge
+		require.True(t, errors.As(err, &challenge))
+		assert.Nil(t, tokens)
+		assert.NotEmpty(t, challenge.Token)
+		assert.WithinDuration(t, time.Now().Add(twoFactorChallengeTTL), challenge.ExpiresAt, time.Second)
+
+		mockSessions.AssertNotCalled(t, "Save", mock.Anything)
+
+		// The challenge does not pass as an access token
+		_, _, err = service.Authenticate(challenge.Token)
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		mockSessions.On("Save", mock.AnythingOfType("*auth.Session")).Return(&Session{ID: uuid.New(), UserID: u.ID}, nil).Once()
+		mockRepo.On("Save", mock.AnythingOfType("*auth.RefreshToken")).Return(&RefreshToken{}, nil).Once()
+		mockUsers.On("VerifyTwoFactor", u.ID.String(), "123456").Return(nil).Once()
+
+		tokens, err = service.LoginTwoFactor(&TwoFactorLoginRequest{ChallengeToken: challenge.Token, Code: "123456"}, testClient)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+		mockUsers.AssertExpectations(t)
+	})
+
+	t.Run("Two-factor auth service: Wrong code", func(t *testing.T) {
+		mockSessions := new(MockSessionRepository)
+		mockUsers := new(user.MockUserService)
+		service := NewAuthService(new(MockRefreshTokenRepository), mockSessions, new(MockAPIKeyRepository), mockUsers, nil, testConfig)
+
+		u := &user.User{ID: uuid.New(), TwoFactorEnabledAt: &enabledAt}
+
+		_, err := service.(*authService).completeLogin(u, testClient)
+
+		var challenge *TwoFactorChallenge
+		require.True(t, errors.As(err, &challenge))
+
+		mockUsers.On("VerifyTwoFactor", u.ID.String(), "000000").Return(user.ErrInvalidCode)
+
+		_, err = service.LoginTwoFactor(&TwoFactorLoginRequest{ChallengeToken: challenge.Token, Code: "000000"}, testClient)
+
+		require.ErrorIs(t, err, user.ErrInvalidCode)
+		mockSessions.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("Two-factor auth service: Access tokens are not challenges", func(t *testing.T) {
+		mockRepo := new(MockRefreshTokenRepository)
+		mockUsers := new(user.MockUserService)
+		service := NewAuthService(mockRepo, new(MockSessionRepository), new(MockAPIKeyRepository), mockUsers, nil, testConfig)
+
+		mockRepo.On("Save", mock.Anything).Return(&RefreshToken{}, nil)
+
+		tokens, err := service.(*authService).issue(uuid.New(), uuid.New())
+		require.NoError(t, err)
+
+		_, err = service.LoginTwoFactor(&TwoFactorLoginRequest{ChallengeToken: tokens.AccessToken, Code: "123456"}, testClient)
+
+		require.ErrorIs(t, err, ErrInvalidTwoFactorChallenge)
+		mockUsers.AssertNotCalled(t, "VerifyTwoFactor", mock.Anything, mock.Anything)
+	})
+}
@@ -1,51 +1,284 @@
 package book
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
+)
+
+// DateLayout is the layout of full dates such as the publication date.
+const DateLayout = "2006-01-02"
+
 type Book struct {
-	ID            uuid.UUID
-	Title         string
-	Author        string
-	PublishedYear int
-	ISBN          string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	DeletedAt     *time.Time
+	ID              uuid.UUID
+	Title           string
+	Subtitle        string
+	OriginalTitle   string
+	Author          string
+	Description     string
+	PublishedYear   int
+	PublicationDate *time.Time
+	ISBN            string
+	PageCount       int
+	Language        string
+	Format          string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       *time.Time
+}
+
+// Translation is a localized variant of a book's title and description.
+type Translation struct {
+	BookID      uuid.UUID
+	Language    string
+	Title       string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// SimilarBook is a book together with its content similarity to another book,
+// between 0 and 1.
+type SimilarBook struct {
+	Book  Book
+	Score float64
+}
+
+var (
+	ErrMergeIntoSelf   = errors.New("a book cannot be merged into itself")
+	ErrInvalidLanguage = errors.New("invalid language parameter")
+)
+
+// MergedBookError is returned when a book was merged into another one. The
+// caller should redirect to TargetID.
+type MergedBookError struct {
+	TargetID string
+}
+
+func (e *MergedBookError) Error() string {
+	return fmt.Sprintf("book has been merged into %s", e.TargetID)
 }
 
 type CreateBookRequest struct {
-	Title         string `json:"title" validate:"required" example:"The Great Gatsby"`
-	Author        string `json:"author" validate:"required" example:"F. Scott Fitzgerald"`
-	PublishedYear int    `json:"published_year" validate:"required,gt=0" example:"1925"`
-	ISBN          string `json:"isbn" validate:"required,isbn13" example:"9780743273565"`
+	Title           string `json:"title" validate:"required" example:"The Great Gatsby"`
+	Subtitle        string `json:"subtitle,omitempty" validate:"omitempty,max=255" example:"A Novel"`
+	OriginalTitle   string `json:"original_title,omitempty" validate:"omitempty,max=255" example:"The Great Gatsby"`
+	Author          string `json:"author" validate:"required" example:"F. Scott Fitzgerald"`
+	Description     string `json:"description,omitempty" example:"A portrait of the Jazz Age in all of its decadence and excess."`
+	PublishedYear   int    `json:"published_year" validate:"required,gt=0,notfuture" example:"1925"`
+	PublicationDate string `json:"publication_date,omitempty" validate:"omitempty,datetime=2006-01-02,notfuture" example:"1925-04-10"`
+	ISBN            string `json:"isbn" validate:"required,isbn13" example:"9780743273565"`
+	PageCount       int    `json:"page_count,omitempty" validate:"omitempty,gt=0" example:"180"`
+	Language        string `json:"language,omitempty" validate:"omitempty,bcp47_language_tag" example:"en-US"`
+	Format          string `json:"format,omitempty" validate:"omitempty,oneof=hardcover paperback ebook audiobook" example:"paperback"`
 }
 
 type UpdateBookRequest struct {
-	Title         string `json:"title" validate:"required" example:"The Great Gatsby"`
-	Author        string `json:"author" validate:"required" example:"F. Scott Fitzgerald"`
-	PublishedYear int    `json:"published_year" validate:"required" example:"1925"`
-	ISBN          string `json:"isbn" validate:"required" example:"9780743273565"`
+	Title           string `json:"title" validate:"required" example:"The Great Gatsby"`
+	Subtitle        string `json:"subtitle,omitempty" validate:"omitempty,max=255" example:"A Novel"`
+	OriginalTitle   string `json:"original_title,omitempty" validate:"omitempty,max=255" example:"The Great Gatsby"`
+	Author          string `json:"author" validate:"required" example:"F. Scott Fitzgerald"`
+	Description     string `json:"description,omitempty" example:"A portrait of the Jazz Age in all of its decadence and excess."`
+	PublishedYear   int    `json:"published_year" validate:"required,notfuture" example:"1925"`
+	PublicationDate string `json:"publication_date,omitempty" validate:"omitempty,datetime=2006-01-02,notfuture" example:"1925-04-10"`
+	ISBN            string `json:"isbn" validate:"required" example:"9780743273565"`
+	PageCount       int    `json:"page_count,omitempty" validate:"omitempty,gt=0" example:"180"`
+	Language        string `json:"language,omitempty" validate:"omitempty,bcp47_language_tag" example:"en-US"`
+	Format          string `json:"format,omitempty" validate:"omitempty,oneof=hardcover paperback ebook audiobook" example:"paperback"`
 }
 
 type CreateBookResponse struct {
-	ID            string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
-	Title         string    `json:"title" example:"The Great Gatsby"`
-	Author        string    `json:"author" example:"F. Scott Fitzgerald"`
-	PublishedYear int       `json:"published_year" example:"1925"`
-	ISBN          string    `json:"isbn" example:"9780743273565"`
-	CreatedAt     time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	ID              string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Title           string    `json:"title" example:"The Great Gatsby"`
+	Subtitle        string    `json:"subtitle,omitempty" example:"A Novel"`
+	OriginalTitle   string    `json:"original_title,omitempty" example:"The Great Gatsby"`
+	Author          string    `json:"author" example:"F. Scott Fitzgerald"`
+	Description     string    `json:"description,omitempty" example:"A portrait of the Jazz Age in all of its decadence and excess."`
+	PublishedYear   int       `json:"published_year" example:"1925"`
+	PublicationDate string    `json:"publication_date,omitempty" example:"1925-04-10"`
+	ISBN            string    `json:"isbn" example:"9780743273565"`
+	PageCount       int       `json:"page_count,omitempty" example:"180"`
+	Language        string    `json:"language,omitempty" example:"en-US"`
+	Format          string    `json:"format,omitempty" example:"paperback"`
+	CreatedAt       time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
 }
 
 type GetBookResponse struct {
-	ID            string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
-	Title         string    `json:"title" example:"The Great Gatsby"`
-	Author        string    `json:"author" example:"F. Scott Fitzgerald"`
-	PublishedYear int       `json:"published_year" example:"1925"`
-	ISBN          string    `json:"isbn" example:"9780743273565"`
-	CreatedAt     time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
-	UpdatedAt     time.Time `json:"updated_at" example:"2024-01-01T00:00:00Z"`
+	ID              string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Title           string    `json:"title" example:"The Great Gatsby"`
+	Subtitle        string    `json:"subtitle,omitempty" example:"A Novel"`
+	OriginalTitle   string    `json:"original_title,omitempty" example:"The Great Gatsby"`
+	Author          string    `json:"author" example:"F. Scott Fitzgerald"`
+	Description     string    `json:"description,omitempty" example:"A portrait of the Jazz Age in all of its decadence and excess."`
+	PublishedYear   int       `json:"published_year" example:"1925"`
+	PublicationDate string    `json:"publication_date,omitempty" example:"1925-04-10"`
+	ISBN            string    `json:"isbn" example:"9780743273565"`
+	PageCount       int       `json:"page_count,omitempty" example:"180"`
+	Language        string    `json:"language,omitempty" example:"en-US"`
+	Format          string    `json:"format,omitempty" example:"paperback"`
+	CreatedAt       time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt       time.Time `json:"updated_at" example:"2024-01-01T00:00:00Z"`
+}
+
+type PutTranslationRequest struct {
+	Title       string `json:"title" validate:"required,max=255" example:"Der große Gatsby"`
+	Description string `json:"description,omitempty" example:"Ein Porträt des Jazz-Zeitalters."`
+}
+
+type TranslationResponse struct {
+	Language    string    `json:"language" example:"de"`
+	Title       string    `json:"title" example:"Der große Gatsby"`
+	Description string    `json:"description,omitempty" example:"Ein Porträt des Jazz-Zeitalters."`
+	CreatedAt   time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2024-01-01T00:00:00Z"`
+}
+
+func newCreateBookResponse(book *Book) CreateBookResponse {
+	return CreateBookResponse{
+		ID:              book.ID.String(),
+		Title:           book.Title,
+		Subtitle:        book.Subtitle,
+		OriginalTitle:   book.OriginalTitle,
+		Author:          book.Author,
+		Description:     book.Description,
+		PublishedYear:   book.PublishedYear,
+		PublicationDate: formatDate(book.PublicationDate),
+		ISBN:            book.ISBN,
+		PageCount:       book.PageCount,
+		Language:        book.Language,
+		Format:          book.Format,
+		CreatedAt:       book.CreatedAt,
+	}
+}
+
+func newGetBookResponse(book *Book) GetBookResponse {
+	return GetBookResponse{
+		ID:              book.ID.String(),
+		Title:           book.Title,
+		Subtitle:        book.Subtitle,
+		OriginalTitle:   book.OriginalTitle,
+		Author:          book.Author,
+		Description:     book.Description,
+		PublishedYear:   book.PublishedYear,
+		PublicationDate: formatDate(book.PublicationDate),
+		ISBN:            book.ISBN,
+		PageCount:       book.PageCount,
+		Language:        book.Language,
+		Format:          book.Format,
+		CreatedAt:       book.CreatedAt,
+		UpdatedAt:       book.UpdatedAt,
+	}
+}
+
+func newTranslationResponse(translation *Translation) TranslationResponse {
+	return TranslationResponse{
+		Language:    translation.Language,
+		Title:       translation.Title,
+		Description: translation.Description,
+		CreatedAt:   translation.CreatedAt,
+		UpdatedAt:   translation.UpdatedAt,
+	}
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(DateLayout)
+}
+
+// parseDate parses an already validated date, returning nil for an empty string.
+func parseDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return nil
+	}
+
+	return &date
+}
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// BatchOperation is a single decoded and validated operation of a batch
+// request. Only the request matching Op is set.
+type BatchOperation struct {
+	Op     string
+	ID     string
+	Create *CreateBookRequest
+	Update *UpdateBookRequest
+}
+
+type BatchResult struct {
+	Op   string
+	ID   string
+	Book *Book
+}
+
+// BatchError reports which operation caused a batch to be rolled back.
+type BatchError struct {
+	Index int
+	Op    string
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %s", e.Index, e.Op, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+type BatchRequest struct {
+	Operations []BatchOperationRequest `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+type BatchOperationRequest struct {
+	Op   string          `json:"op" validate:"required,oneof=create update delete" example:"update"`
+	ID   string          `json:"id,omitempty" validate:"required_unless=Op create,excluded_if=Op create,omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Book json.RawMessage `json:"book,omitempty" swaggertype:"object"`
+}
+
+type BatchOperationResponse struct {
+	Op   string              `json:"op" example:"update"`
+	ID   string              `json:"id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Book *CreateBookResponse `json:"book,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchOperationResponse `json:"results"`
+}
+
+type MergeBookRequest struct {
+	SourceID string `json:"source_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+}
+
+type SimilarBookResponse struct {
+	Book  GetBookResponse `json:"book"`
+	Score float64         `json:"score" example:"0.42"`
+}
+
+func newSimilarBookResponse(similar *SimilarBook) SimilarBookResponse {
+	return SimilarBookResponse{
+		Book:  newGetBookResponse(&similar.Book),
+		Score: similar.Score,
+	}
 }
@@ -1,8 +1,15 @@
 package book
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/jakottelaar/gobookreviewapp/pkg/common"
 )
@@ -25,6 +32,7 @@ func NewBookHandler(service BookService) *BookHandler {
 // @Produce json
 // @Param book body CreateBookRequest true "Book details"
 // @Success 201 {object} CreateBookResponse
+// @Security BearerAuth
 // @Router /books [post]
 func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request) {
 	var req CreateBookRequest
@@ -36,7 +44,7 @@ func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate := NewValidator()
 
 	err = validate.Struct(req)
 
@@ -58,14 +66,7 @@ func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := CreateBookResponse{
-		ID:            createdBook.ID.String(),
-		Title:         createdBook.Title,
-		Author:        createdBook.Author,
-		PublishedYear: createdBook.PublishedYear,
-		ISBN:          createdBook.ISBN,
-		CreatedAt:     createdBook.CreatedAt,
-	}
+	resp := newCreateBookResponse(createdBook)
 
 	err = common.WriteJSON(w, http.StatusCreated, common.Envelope{"book": resp}, nil)
 	if err != nil {
@@ -81,7 +82,9 @@ func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Book ID"
+// @Param Accept-Language header string false "Preferred languages for the title and description"
 // @Success 200 {object} GetBookResponse
+// @Success 308 {object} interface{} "Book was merged, follow the Location header"
 // @Router /books/{id} [get]
 func (h *BookHandler) GetBookById(w http.ResponseWriter, r *http.Request) {
 	id, err := common.GetIdFromRequest(r, "id")
@@ -94,8 +97,12 @@ func (h *BookHandler) GetBookById(w http.ResponseWriter, r *http.Request) {
 	book, err := h.service.GetBookById(id)
 
 	if err != nil {
-		switch err {
-		case common.ErrNotFound:
+		var mergedErr *MergedBookError
+
+		switch {
+		case errors.As(err, &mergedErr):
+			h.redirectToMergedBook(w, r, id, mergedErr.TargetID)
+		case errors.Is(err, common.ErrNotFound):
 			common.NotFoundResponse(w, r)
 		default:
 			common.ServerErrorResponse(w, r, err)
@@ -103,17 +110,26 @@ func (h *BookHandler) GetBookById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := GetBookResponse{
-		ID:            book.ID.String(),
-		Title:         book.Title,
-		Author:        book.Author,
-		PublishedYear: book.PublishedYear,
-		ISBN:          book.ISBN,
-		CreatedAt:     book.CreatedAt,
-		UpdatedAt:     book.UpdatedAt,
+	headers := make(http.Header)
+	headers.Set("Vary", "Accept-Language")
+
+	if acceptLanguage := r.Header.Get("Accept-Language"); acceptLanguage != "" {
+		var contentLanguage string
+
+		book, contentLanguage, err = h.service.Localize(book, acceptLanguage)
+		if err != nil {
+			common.ServerErrorResponse(w, r, err)
+			return
+		}
+
+		if contentLanguage != "" {
+			headers.Set("Content-Language", contentLanguage)
+		}
 	}
 
-	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"book": resp}, nil)
+	resp := newGetBookResponse(book)
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"book": resp}, headers)
 	if err != nil {
 		common.ServerErrorResponse(w, r, err)
 		return
@@ -129,6 +145,7 @@ func (h *BookHandler) GetBookById(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Book ID" format(uuid)
 // @Param book body UpdateBookRequest true "Book details"
 // @Success 200 {object} CreateBookResponse
+// @Security BearerAuth
 // @Router /books/{id} [put]
 func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	id, err := common.GetIdFromRequest(r, "id")
@@ -147,7 +164,7 @@ func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate := NewValidator()
 
 	err = validate.Struct(req)
 
@@ -174,14 +191,7 @@ func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := CreateBookResponse{
-		ID:            book.ID.String(),
-		Title:         book.Title,
-		Author:        book.Author,
-		PublishedYear: book.PublishedYear,
-		ISBN:          book.ISBN,
-		CreatedAt:     book.CreatedAt,
-	}
+	resp := newCreateBookResponse(book)
 
 	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"book": resp}, nil)
 	if err != nil {
@@ -198,6 +208,7 @@ func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Book ID"
 // @Success 200 {object} interface{}
+// @Security BearerAuth
 // @Router /books/{id} [delete]
 func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	id, err := common.GetIdFromRequest(r, "id")
@@ -225,3 +236,451 @@ func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// MergeBook godoc
+// @Summary Merge a duplicate book into another book
+// @Description Fold the source book into the book identified by the path. The source is soft-deleted, its translations are moved to the target unless the target already has one in the same language, and requests for it are redirected to the target.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path string true "Target book ID" format(uuid)
+// @Param merge body MergeBookRequest true "Book to merge"
+// @Success 200 {object} GetBookResponse
+// @Security BearerAuth
+// @Router /books/{id}/merge [post]
+func (h *BookHandler) MergeBook(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var req MergeBookRequest
+
+	err = common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	validate := NewValidator()
+
+	err = validate.Struct(req)
+
+	if err != nil {
+		errors := make(map[string]string)
+
+		for _, err := range err.(validator.ValidationErrors) {
+			errors[err.Field()] = err.Tag()
+		}
+
+		common.FailedValidationResponse(w, r, errors)
+		return
+	}
+
+	book, err := h.service.Merge(req.SourceID, id)
+
+	if err != nil {
+		switch err {
+		case ErrMergeIntoSelf:
+			common.FailedValidationResponse(w, r, map[string]string{"SourceID": err.Error()})
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	resp := newGetBookResponse(book)
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"book": resp}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ListTranslations godoc
+// @Summary List the translations of a book
+// @Description List the localized titles and descriptions of a book
+// @Tags translations
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID" format(uuid)
+// @Success 200 {array} TranslationResponse
+// @Success 308 {object} interface{} "Book was merged, follow the Location header"
+// @Router /books/{id}/translations [get]
+func (h *BookHandler) ListTranslations(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	translations, err := h.service.ListTranslations(id)
+
+	if err != nil {
+		var mergedErr *MergedBookError
+
+		switch {
+		case errors.As(err, &mergedErr):
+			h.redirectToMergedBook(w, r, id, mergedErr.TargetID)
+		case errors.Is(err, common.ErrNotFound):
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	resp := make([]TranslationResponse, len(translations))
+	for i := range translations {
+		resp[i] = newTranslationResponse(&translations[i])
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"translations": resp}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// PutTranslation godoc
+// @Summary Create or replace a translation of a book
+// @Description Store the localized title and description of a book for a BCP 47 language tag
+// @Tags translations
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID" format(uuid)
+// @Param language path string true "BCP 47 language tag" example(de)
+// @Param translation body PutTranslationRequest true "Translation details"
+// @Success 200 {object} TranslationResponse
+// @Success 308 {object} interface{} "Book was merged, follow the Location header"
+// @Security BearerAuth
+// @Router /books/{id}/translations/{language} [put]
+func (h *BookHandler) PutTranslation(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var req PutTranslationRequest
+
+	err = common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	validate := NewValidator()
+
+	err = validate.Struct(req)
+
+	if err != nil {
+		errors := make(map[string]string)
+
+		for _, err := range err.(validator.ValidationErrors) {
+			errors[err.Field()] = err.Tag()
+		}
+
+		common.FailedValidationResponse(w, r, errors)
+		return
+	}
+
+	translation, err := h.service.PutTranslation(id, chi.URLParam(r, "language"), &req)
+
+	if err != nil {
+		var mergedErr *MergedBookError
+
+		switch {
+		case errors.As(err, &mergedErr):
+			h.redirectToMergedBook(w, r, id, mergedErr.TargetID)
+		case errors.Is(err, ErrInvalidLanguage):
+			common.BadRequestResponse(w, r, err)
+		case errors.Is(err, common.ErrNotFound):
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"translation": newTranslationResponse(translation)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// DeleteTranslation godoc
+// @Summary Delete a translation of a book
+// @Description Delete the localized title and description of a book for a language
+// @Tags translations
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID" format(uuid)
+// @Param language path string true "BCP 47 language tag" example(de)
+// @Success 200 {object} interface{}
+// @Success 308 {object} interface{} "Book was merged, follow the Location header"
+// @Security BearerAuth
+// @Router /books/{id}/translations/{language} [delete]
+func (h *BookHandler) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	err = h.service.DeleteTranslation(id, chi.URLParam(r, "language"))
+
+	if err != nil {
+		var mergedErr *MergedBookError
+
+		switch {
+		case errors.As(err, &mergedErr):
+			h.redirectToMergedBook(w, r, id, mergedErr.TargetID)
+		case errors.Is(err, ErrInvalidLanguage):
+			common.BadRequestResponse(w, r, err)
+		case errors.Is(err, common.ErrNotFound):
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"message": "Successfully deleted translation"}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// SimilarBooks godoc
+// @Summary Get books similar to a book
+// @Description Get the books whose title, description and author are most similar to those of the given book, most similar first
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID" format(uuid)
+// @Param limit query int false "Maximum number of books" minimum(1) maximum(50) default(10)
+// @Success 200 {array} SimilarBookResponse
+// @Router /books/{id}/similar [get]
+func (h *BookHandler) SimilarBooks(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	limit := 10
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+
+		switch {
+		case err != nil:
+			common.FailedValidationResponse(w, r, map[string]string{"limit": "number"})
+			return
+		case limit < 1:
+			common.FailedValidationResponse(w, r, map[string]string{"limit": "min"})
+			return
+		case limit > 50:
+			common.FailedValidationResponse(w, r, map[string]string{"limit": "max"})
+			return
+		}
+	}
+
+	similar, err := h.service.Similar(id, limit)
+
+	if err != nil {
+		var mergedErr *MergedBookError
+
+		switch {
+		case errors.As(err, &mergedErr):
+			h.redirectToMergedBook(w, r, id, mergedErr.TargetID)
+		case errors.Is(err, common.ErrNotFound):
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	resp := make([]SimilarBookResponse, len(similar))
+	for i := range similar {
+		resp[i] = newSimilarBookResponse(&similar[i])
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"books": resp}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// redirectToMergedBook answers a request for a merged book with a permanent
+// redirect to the book it was merged into.
+func (h *BookHandler) redirectToMergedBook(w http.ResponseWriter, r *http.Request, id, targetID string) {
+	location := strings.Replace(r.URL.Path, id, targetID, 1)
+
+	headers := make(http.Header)
+	headers.Set("Location", location)
+
+	env := common.Envelope{"message": "book has been merged", "location": location}
+
+	err := common.WriteJSON(w, http.StatusPermanentRedirect, env, headers)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+	}
+}
+
+// Batch godoc
+// @Summary Apply several book operations atomically
+// @Description Create, update and delete books in a single transaction. Operations are applied in order and all of them are rolled back if any fails.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param batch body BatchRequest true "Ordered list of operations"
+// @Success 200 {object} BatchResponse
+// @Security BearerAuth
+// @Router /batch [post]
+func (h *BookHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	validate := NewValidator()
+
+	err = validate.Struct(req)
+
+	if err != nil {
+		errors := make(map[string]string)
+
+		for _, err := range err.(validator.ValidationErrors) {
+			errors[strings.TrimPrefix(err.Namespace(), "BatchRequest.")] = err.Tag()
+		}
+
+		common.FailedValidationResponse(w, r, errors)
+		return
+	}
+
+	ops := make([]BatchOperation, len(req.Operations))
+	validationErrors := make(map[string]string)
+
+	for i, opReq := range req.Operations {
+		op := BatchOperation{Op: opReq.Op, ID: opReq.ID}
+
+		var payload any
+		switch opReq.Op {
+		case BatchOpCreate:
+			op.Create = &CreateBookRequest{}
+			payload = op.Create
+		case BatchOpUpdate:
+			op.Update = &UpdateBookRequest{}
+			payload = op.Update
+		}
+
+		if payload != nil {
+			err = decodeOperationBook(opReq.Book, payload)
+			if err != nil {
+				common.BadRequestResponse(w, r, fmt.Errorf("operations[%d]: %w", i, err))
+				return
+			}
+
+			err = validate.Struct(payload)
+			if err != nil {
+				for _, err := range err.(validator.ValidationErrors) {
+					validationErrors[fmt.Sprintf("Operations[%d].Book.%s", i, err.Field())] = err.Tag()
+				}
+			}
+		}
+
+		ops[i] = op
+	}
+
+	if len(validationErrors) > 0 {
+		common.FailedValidationResponse(w, r, validationErrors)
+		return
+	}
+
+	results, err := h.service.Batch(ops)
+
+	if err != nil {
+		var batchErr *BatchError
+
+		switch {
+		case errors.As(err, &batchErr) && errors.Is(batchErr.Err, common.ErrNotFound):
+			env := common.Envelope{"error": batchErr.Err.Error(), "operation": batchErr.Index}
+			err = common.WriteJSON(w, http.StatusNotFound, env, nil)
+			if err != nil {
+				common.ServerErrorResponse(w, r, err)
+			}
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	resp := make([]BatchOperationResponse, len(results))
+
+	for i, result := range results {
+		resp[i] = BatchOperationResponse{
+			Op: result.Op,
+			ID: result.ID,
+		}
+
+		if result.Book != nil {
+			resp[i].ID = result.Book.ID.String()
+			bookResp := newCreateBookResponse(result.Book)
+			resp[i].Book = &bookResp
+		}
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"results": resp}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// decodeOperationBook decodes the book payload of a single batch operation
+// with the same strictness as common.ReadJSON.
+func decodeOperationBook(raw json.RawMessage, dst any) error {
+	if len(raw) == 0 {
+		return errors.New("book must not be empty")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var unmarshalTypeError *json.UnmarshalTypeError
+
+		switch {
+		case errors.As(err, &unmarshalTypeError):
+			return fmt.Errorf("book contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("book contains unknown key %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		default:
+			return fmt.Errorf("book is invalid: %w", err)
+		}
+	}
+
+	return nil
+}
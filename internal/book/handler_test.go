@@ -137,6 +137,110 @@ func TestCreateBookHandler(t *testing.T) {
 	})
 }
 
+func TestCreateBookMetadataHandler(t *testing.T) {
+	mockService := new(MockBookService)
+	handler := NewBookHandler(mockService)
+
+	t.Run("POST Book handler: Successfully create a book with metadata", func(t *testing.T) {
+		reqBody := CreateBookRequest{
+			Title:           "Test Book",
+			Subtitle:        "A Subtitle",
+			OriginalTitle:   "Testbuch",
+			Author:          "Test Author",
+			Description:     "A book about testing.",
+			PublishedYear:   2004,
+			PublicationDate: "2004-05-01",
+			ISBN:            "9780743273565",
+			PageCount:       320,
+			Language:        "de-DE",
+			Format:          FormatHardcover,
+		}
+
+		publicationDate := time.Date(2004, 5, 1, 0, 0, 0, 0, time.UTC)
+
+		expectedBook := &Book{
+			ID:              uuid.New(),
+			Title:           reqBody.Title,
+			Subtitle:        reqBody.Subtitle,
+			OriginalTitle:   reqBody.OriginalTitle,
+			Author:          reqBody.Author,
+			Description:     reqBody.Description,
+			PublishedYear:   reqBody.PublishedYear,
+			PublicationDate: &publicationDate,
+			ISBN:            reqBody.ISBN,
+			PageCount:       reqBody.PageCount,
+			Language:        reqBody.Language,
+			Format:          reqBody.Format,
+			CreatedAt:       time.Now(),
+		}
+
+		mockService.On("Create", mock.AnythingOfType("*book.CreateBookRequest")).Return(expectedBook, nil)
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/books", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		handler.CreateBook(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		book, ok := response["book"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "A Subtitle", book["subtitle"])
+		assert.Equal(t, "Testbuch", book["original_title"])
+		assert.Equal(t, "A book about testing.", book["description"])
+		assert.Equal(t, "2004-05-01", book["publication_date"])
+		assert.Equal(t, float64(320), book["page_count"])
+		assert.Equal(t, "de-DE", book["language"])
+		assert.Equal(t, "hardcover", book["format"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Book handler: Invalid metadata", func(t *testing.T) {
+		nextYear := time.Now().Year() + 1
+
+		reqBody := CreateBookRequest{
+			Title:           "Test Book",
+			Author:          "Test Author",
+			PublishedYear:   nextYear,
+			PublicationDate: time.Now().AddDate(0, 0, 2).Format(DateLayout),
+			ISBN:            "9780743273565",
+			PageCount:       -5,
+			Language:        "not a language",
+			Format:          "scroll",
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/books", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		handler.CreateBook(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		errors, ok := response["error"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "notfuture", errors["PublishedYear"])
+		assert.Equal(t, "notfuture", errors["PublicationDate"])
+		assert.Equal(t, "gt", errors["PageCount"])
+		assert.Equal(t, "bcp47_language_tag", errors["Language"])
+		assert.Equal(t, "oneof", errors["Format"])
+	})
+}
+
 func TestGetBookHandler(t *testing.T) {
 	mockService := new(MockBookService)
 	handler := NewBookHandler(mockService)
@@ -206,6 +310,288 @@ func TestGetBookHandler(t *testing.T) {
 	})
 }
 
+func TestGetMergedBookHandler(t *testing.T) {
+	mockService := new(MockBookService)
+	handler := NewBookHandler(mockService)
+
+	t.Run("GET Book by id handler: Redirect to merged book", func(t *testing.T) {
+		bookID := uuid.New()
+		targetID := uuid.New()
+
+		mockService.On("GetBookById", bookID.String()).Return((*Book)(nil), &MergedBookError{TargetID: targetID.String()})
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/api/books/"+bookID.String(), nil)
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Get("/v1/api/books/{id}", handler.GetBookById)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "/v1/api/books/"+targetID.String(), w.Header().Get("Location"))
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestSimilarBooksHandler(t *testing.T) {
+	mockService := new(MockBookService)
+	handler := NewBookHandler(mockService)
+
+	r := chi.NewRouter()
+	r.Get("/v1/api/books/{id}/similar", handler.SimilarBooks)
+
+	t.Run("GET Similar books handler: Successfully get similar books", func(t *testing.T) {
+		bookID := uuid.New()
+		similarID := uuid.New()
+
+		similar := []SimilarBook{{Book: Book{ID: similarID, Title: "Tender Is the Night", Author: "F. Scott Fitzgerald"}, Score: 0.42}}
+
+		mockService.On("Similar", bookID.String(), 5).Return(similar, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/api/books/"+bookID.String()+"/similar?limit=5", nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		books, ok := response["books"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, books, 1)
+
+		resp := books[0].(map[string]interface{})
+		assert.Equal(t, 0.42, resp["score"])
+		assert.Equal(t, similarID.String(), resp["book"].(map[string]interface{})["id"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GET Similar books handler: Invalid limit", func(t *testing.T) {
+		bookID := uuid.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/api/books/"+bookID.String()+"/similar?limit=500", nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "Similar", bookID.String(), mock.Anything)
+	})
+
+	t.Run("GET Similar books handler: Redirect to merged book", func(t *testing.T) {
+		bookID := uuid.New()
+		targetID := uuid.New()
+
+		mockService.On("Similar", bookID.String(), 10).Return([]SimilarBook(nil), &MergedBookError{TargetID: targetID.String()})
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/api/books/"+bookID.String()+"/similar", nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "/v1/api/books/"+targetID.String()+"/similar", w.Header().Get("Location"))
+	})
+}
+
+func TestMergeBookHandler(t *testing.T) {
+	mockService := new(MockBookService)
+	handler := NewBookHandler(mockService)
+
+	t.Run("POST Merge book handler: Successfully merge a book", func(t *testing.T) {
+		sourceID := uuid.New()
+		targetID := uuid.New()
+
+		target := &Book{
+			ID:            targetID,
+			Title:         "Test Book",
+			Author:        "Test Author",
+			PublishedYear: 2004,
+			ISBN:          "9780743273565",
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+
+		mockService.On("Merge", sourceID.String(), targetID.String()).Return(target, nil)
+
+		body, _ := json.Marshal(MergeBookRequest{SourceID: sourceID.String()})
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/books/"+targetID.String()+"/merge", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Post("/v1/api/books/{id}/merge", handler.MergeBook)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		book, ok := response["book"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, targetID.String(), book["id"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Merge book handler: Merge into itself", func(t *testing.T) {
+		bookID := uuid.New()
+
+		mockService.On("Merge", bookID.String(), bookID.String()).Return((*Book)(nil), ErrMergeIntoSelf)
+
+		body, _ := json.Marshal(MergeBookRequest{SourceID: bookID.String()})
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/books/"+bookID.String()+"/merge", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Post("/v1/api/books/{id}/merge", handler.MergeBook)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetLocalizedBookHandler(t *testing.T) {
+	mockService := new(MockBookService)
+	handler := NewBookHandler(mockService)
+
+	t.Run("GET Book by id handler: Localized title from Accept-Language", func(t *testing.T) {
+		bookID := uuid.New()
+
+		book := &Book{ID: bookID, Title: "The Great Gatsby", Author: "F. Scott Fitzgerald", Language: "en"}
+		localized := &Book{ID: bookID, Title: "Der große Gatsby", Author: "F. Scott Fitzgerald", Language: "en"}
+
+		mockService.On("GetBookById", bookID.String()).Return(book, nil)
+		mockService.On("Localize", book, "de").Return(localized, "de", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/api/books/"+bookID.String(), nil)
+		req.Header.Set("Accept-Language", "de")
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Get("/v1/api/books/{id}", handler.GetBookById)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "de", w.Header().Get("Content-Language"))
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		resp, ok := response["book"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "Der große Gatsby", resp["title"])
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestTranslationHandlers(t *testing.T) {
+	mockService := new(MockBookService)
+	handler := NewBookHandler(mockService)
+
+	t.Run("PUT Translation handler: Successfully store a translation", func(t *testing.T) {
+		bookID := uuid.New()
+
+		translation := &Translation{
+			BookID:    bookID,
+			Language:  "de",
+			Title:     "Der große Gatsby",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		mockService.On("PutTranslation", bookID.String(), "de", mock.AnythingOfType("*book.PutTranslationRequest")).Return(translation, nil)
+
+		body, _ := json.Marshal(PutTranslationRequest{Title: "Der große Gatsby"})
+		req := httptest.NewRequest(http.MethodPut, "/v1/api/books/"+bookID.String()+"/translations/de", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Put("/v1/api/books/{id}/translations/{language}", handler.PutTranslation)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		resp, ok := response["translation"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "de", resp["language"])
+		assert.Equal(t, "Der große Gatsby", resp["title"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PUT Translation handler: Missing title", func(t *testing.T) {
+		bookID := uuid.New()
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/api/books/"+bookID.String()+"/translations/de", bytes.NewReader([]byte(`{"description": "Beschreibung"}`)))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Put("/v1/api/books/{id}/translations/{language}", handler.PutTranslation)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("DELETE Translation handler: Translation not found", func(t *testing.T) {
+		bookID := uuid.New()
+
+		mockService.On("DeleteTranslation", bookID.String(), "fr").Return(common.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodDelete, "/v1/api/books/"+bookID.String()+"/translations/fr", nil)
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Delete("/v1/api/books/{id}/translations/{language}", handler.DeleteTranslation)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GET Translations handler: Book was merged", func(t *testing.T) {
+		bookID := uuid.New()
+		targetID := uuid.New()
+
+		mockService.On("ListTranslations", bookID.String()).Return([]Translation(nil), &MergedBookError{TargetID: targetID.String()})
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/api/books/"+bookID.String()+"/translations", nil)
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Get("/v1/api/books/{id}/translations", handler.ListTranslations)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "/v1/api/books/"+targetID.String()+"/translations", w.Header().Get("Location"))
+
+		mockService.AssertExpectations(t)
+	})
+}
+
 func TestUpdateBookHandler(t *testing.T) {
 	mockService := new(MockBookService)
 	handler := NewBookHandler(mockService)
@@ -368,3 +754,123 @@ func TestDeleteBookHandler(t *testing.T) {
 	})
 
 }
+
+func TestBatchHandler(t *testing.T) {
+	mockService := new(MockBookService)
+	handler := NewBookHandler(mockService)
+
+	t.Run("POST Batch handler: Successfully apply operations", func(t *testing.T) {
+		bookID := uuid.New()
+
+		createdBook := &Book{
+			ID:            uuid.New(),
+			Title:         "Test Book",
+			Author:        "Test Author",
+			PublishedYear: 2004,
+			ISBN:          "9780743273565",
+			CreatedAt:     time.Now(),
+		}
+
+		mockService.On("Batch", mock.AnythingOfType("[]book.BatchOperation")).Return([]BatchResult{
+			{Op: BatchOpCreate, Book: createdBook},
+			{Op: BatchOpDelete, ID: bookID.String()},
+		}, nil).Once()
+
+		reqBody := `{"operations": [
+			{"op": "create", "book": {"title": "Test Book", "author": "Test Author", "published_year": 2004, "isbn": "9780743273565"}},
+			{"op": "delete", "id": "` + bookID.String() + `"}
+		]}`
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/batch", bytes.NewReader([]byte(reqBody)))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		handler.Batch(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		results, ok := response["results"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, results, 2)
+
+		created := results[0].(map[string]interface{})
+		assert.Equal(t, "create", created["op"])
+		assert.Equal(t, createdBook.ID.String(), created["id"])
+		assert.Contains(t, created, "book")
+
+		deleted := results[1].(map[string]interface{})
+		assert.Equal(t, "delete", deleted["op"])
+		assert.Equal(t, bookID.String(), deleted["id"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Batch handler: Invalid operation book", func(t *testing.T) {
+		reqBody := `{"operations": [
+			{"op": "update", "id": "` + uuid.New().String() + `", "book": {"title": "Only a title"}}
+		]}`
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/batch", bytes.NewReader([]byte(reqBody)))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		handler.Batch(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Contains(t, response["error"], "Operations[0].Book.Author")
+	})
+
+	t.Run("POST Batch handler: Missing id for delete", func(t *testing.T) {
+		reqBody := `{"operations": [{"op": "delete"}]}`
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/batch", bytes.NewReader([]byte(reqBody)))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		handler.Batch(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Contains(t, response["error"], "Operations[0].ID")
+	})
+
+	t.Run("POST Batch handler: Rolled back on missing book", func(t *testing.T) {
+		bookID := uuid.New()
+
+		mockService.On("Batch", mock.AnythingOfType("[]book.BatchOperation")).Return([]BatchResult(nil), &BatchError{Index: 0, Op: BatchOpDelete, Err: common.ErrNotFound}).Once()
+
+		reqBody := `{"operations": [{"op": "delete", "id": "` + bookID.String() + `"}]}`
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/batch", bytes.NewReader([]byte(reqBody)))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		handler.Batch(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, float64(0), response["operation"])
+		assert.Contains(t, response, "error")
+	})
+}
@@ -20,6 +20,10 @@ func (m *MockBookService) GetBookById(id string) (*Book, error) {
 	return args.Get(0).(*Book), args.Error(1)
 }
 
+func (m *MockBookService) Reindex(book *Book) {
+	m.Called(book)
+}
+
 func (m *MockBookService) Update(id string, req *UpdateBookRequest) (*Book, error) {
 	args := m.Called(id, req)
 	return args.Get(0).(*Book), args.Error(1)
@@ -40,6 +44,11 @@ func (m *MockBookRepository) FindById(id string) (*Book, error) {
 	return args.Get(0).(*Book), args.Error(1)
 }
 
+func (m *MockBookRepository) FindByIdForUpdate(id string) (*Book, error) {
+	args := m.Called(id)
+	return args.Get(0).(*Book), args.Error(1)
+}
+
 func (m *MockBookRepository) Update(book *Book) (*Book, error) {
 	args := m.Called(book)
 	return args.Get(0).(*Book), args.Error(1)
@@ -49,3 +58,83 @@ func (m *MockBookRepository) Delete(id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+
+func (m *MockBookService) Batch(ops []BatchOperation) ([]BatchResult, error) {
+	args := m.Called(ops)
+	return args.Get(0).([]BatchResult), args.Error(1)
+}
+
+// Transaction runs fn against the mock itself so expectations set on the
+// repository also apply to calls made inside the transaction.
+func (m *MockBookRepository) Transaction(fn func(repo BookRepository) error) error {
+	args := m.Called()
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+func (m *MockBookService) Merge(sourceID, targetID string) (*Book, error) {
+	args := m.Called(sourceID, targetID)
+	return args.Get(0).(*Book), args.Error(1)
+}
+
+func (m *MockBookRepository) Merge(sourceID, targetID string) error {
+	args := m.Called(sourceID, targetID)
+	return args.Error(0)
+}
+
+func (m *MockBookRepository) FindRedirect(id string) (string, error) {
+	args := m.Called(id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBookService) Localize(book *Book, acceptLanguage string) (*Book, string, error) {
+	args := m.Called(book, acceptLanguage)
+	return args.Get(0).(*Book), args.String(1), args.Error(2)
+}
+
+func (m *MockBookService) ListTranslations(bookID string) ([]Translation, error) {
+	args := m.Called(bookID)
+	return args.Get(0).([]Translation), args.Error(1)
+}
+
+func (m *MockBookService) PutTranslation(bookID, lang string, req *PutTranslationRequest) (*Translation, error) {
+	args := m.Called(bookID, lang, req)
+	return args.Get(0).(*Translation), args.Error(1)
+}
+
+func (m *MockBookService) DeleteTranslation(bookID, lang string) error {
+	args := m.Called(bookID, lang)
+	return args.Error(0)
+}
+
+func (m *MockBookRepository) FindTranslations(bookID string) ([]Translation, error) {
+	args := m.Called(bookID)
+	return args.Get(0).([]Translation), args.Error(1)
+}
+
+func (m *MockBookRepository) SaveTranslation(translation *Translation) (*Translation, error) {
+	args := m.Called(translation)
+	return args.Get(0).(*Translation), args.Error(1)
+}
+
+func (m *MockBookRepository) DeleteTranslation(bookID, language string) error {
+	args := m.Called(bookID, language)
+	return args.Error(0)
+}
+
+func (m *MockBookService) BuildSimilarityIndex() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockBookService) Similar(id string, limit int) ([]SimilarBook, error) {
+	args := m.Called(id, limit)
+	return args.Get(0).([]SimilarBook), args.Error(1)
+}
+
+func (m *MockBookRepository) FindAll() ([]Book, error) {
+	args := m.Called()
+	return args.Get(0).([]Book), args.Error(1)
+}
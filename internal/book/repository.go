@@ -4,38 +4,69 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/database"
 )
 
 type BookRepository interface {
 	FindById(id string) (*Book, error)
+	FindByIdForUpdate(id string) (*Book, error)
+	FindAll() ([]Book, error)
 	Save(book *Book) (*Book, error)
 	Update(book *Book) (*Book, error)
 	Delete(id string) error
+	Merge(sourceID, targetID string) error
+	FindRedirect(id string) (string, error)
+	FindTranslations(bookID string) ([]Translation, error)
+	SaveTranslation(translation *Translation) (*Translation, error)
+	DeleteTranslation(bookID, language string) error
+	Transaction(fn func(repo BookRepository) error) error
 }
 
 type bookRepository struct {
-	db *sql.DB
+	db   database.DBTX
+	conn *sql.DB
 }
 
 func NewBookRepository(db *sql.DB) BookRepository {
+	return &bookRepository{
+		db:   db,
+		conn: db,
+	}
+}
+
+// NewTxBookRepository returns a repository that runs its queries on db, so
+// other packages can change books inside their own transactions.
+func NewTxBookRepository(db database.DBTX) BookRepository {
 	return &bookRepository{
 		db: db,
 	}
 }
 
+func (r *bookRepository) Transaction(fn func(repo BookRepository) error) error {
+	if r.conn == nil {
+		return errors.New("transaction already in progress")
+	}
+
+	return database.Transaction(context.Background(), r.conn, func(tx *sql.Tx) error {
+		return fn(&bookRepository{db: tx})
+	})
+}
+
 func (r *bookRepository) Save(book *Book) (*Book, error) {
 	query := `
-		INSERT INTO books (id, title, author, published_year, isbn) 
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO books (id, title, subtitle, original_title, author, description, published_year, publication_date, isbn, page_count, language, format) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, created_at`
 
-	err := r.db.QueryRow(query, book.ID, book.Title, book.Author, book.PublishedYear, book.ISBN).Scan(&book.ID, &book.CreatedAt)
+	args := []any{book.ID, book.Title, book.Subtitle, book.OriginalTitle, book.Author, book.Description, book.PublishedYear, book.PublicationDate, book.ISBN, book.PageCount, book.Language, book.Format}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 
-	fmt.Println("Book created at: ", book.CreatedAt)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&book.ID, &book.CreatedAt)
 
 	if err != nil {
 		return nil, err
@@ -47,16 +78,47 @@ func (r *bookRepository) Save(book *Book) (*Book, error) {
 
 func (r *bookRepository) FindById(id string) (*Book, error) {
 	query := `
-		SELECT id, title, author, published_year, isbn, created_at, updated_at
+		SELECT id, title, subtitle, original_title, author, description, published_year, publication_date, isbn, page_count, language, format, created_at, updated_at
 		FROM books
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	return r.findOne(query, id)
+}
+
+// FindByIdForUpdate locks the book until the transaction ends. It should be
+// called inside Transaction.
+func (r *bookRepository) FindByIdForUpdate(id string) (*Book, error) {
+	query := `
+		SELECT id, title, subtitle, original_title, author, description, published_year, publication_date, isbn, page_count, language, format, created_at, updated_at
+		FROM books
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE`
 
+	return r.findOne(query, id)
+}
+
+func (r *bookRepository) findOne(query string, args ...any) (*Book, error) {
 	var book Book
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&book.ID, &book.Title, &book.Author, &book.PublishedYear, &book.ISBN, &book.CreatedAt, &book.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&book.ID,
+		&book.Title,
+		&book.Subtitle,
+		&book.OriginalTitle,
+		&book.Author,
+		&book.Description,
+		&book.PublishedYear,
+		&book.PublicationDate,
+		&book.ISBN,
+		&book.PageCount,
+		&book.Language,
+		&book.Format,
+		&book.CreatedAt,
+		&book.UpdatedAt,
+	)
 
 	if err != nil {
 		switch {
@@ -70,17 +132,71 @@ func (r *bookRepository) FindById(id string) (*Book, error) {
 	return &book, nil
 }
 
+func (r *bookRepository) FindAll() ([]Book, error) {
+	query := `
+		SELECT id, title, subtitle, original_title, author, description, published_year, publication_date, isbn, page_count, language, format, created_at, updated_at
+		FROM books
+		WHERE deleted_at IS NULL
+		ORDER BY created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []Book{}
+
+	for rows.Next() {
+		var book Book
+
+		err := rows.Scan(
+			&book.ID,
+			&book.Title,
+			&book.Subtitle,
+			&book.OriginalTitle,
+			&book.Author,
+			&book.Description,
+			&book.PublishedYear,
+			&book.PublicationDate,
+			&book.ISBN,
+			&book.PageCount,
+			&book.Language,
+			&book.Format,
+			&book.CreatedAt,
+			&book.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		books = append(books, book)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
 func (r *bookRepository) Update(book *Book) (*Book, error) {
 	query := `
 		UPDATE books
-		SET title = $1, author = $2, published_year = $3, isbn = $4
-		WHERE id = $5
-		RETURNING created_at`
+		SET title = $1, subtitle = $2, original_title = $3, author = $4, description = $5, published_year = $6,
+			publication_date = $7, isbn = $8, page_count = $9, language = $10, format = $11
+		WHERE id = $12 AND deleted_at IS NULL
+		RETURNING created_at, updated_at`
+
+	args := []any{book.Title, book.Subtitle, book.OriginalTitle, book.Author, book.Description, book.PublishedYear, book.PublicationDate, book.ISBN, book.PageCount, book.Language, book.Format, book.ID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := r.db.QueryRowContext(ctx, query, book.Title, book.Author, book.PublishedYear, book.ISBN, book.ID).Scan(&book.CreatedAt)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&book.CreatedAt, &book.UpdatedAt)
 
 	if err != nil {
 		switch {
@@ -118,3 +234,189 @@ func (r *bookRepository) Delete(id string) error {
 
 	return nil
 }
+
+// Merge locks both books in ID order, so concurrent merges of the same pair
+// wait for each other. It should be called inside Transaction.
+func (r *bookRepository) Merge(sourceID, targetID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id
+		FROM books
+		WHERE id IN ($1, $2) AND deleted_at IS NULL
+		ORDER BY id
+		FOR UPDATE`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	locked := 0
+	for rows.Next() {
+		locked++
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if locked < 2 {
+		return common.ErrNotFound
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE books
+		SET deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO book_translations (book_id, language, title, description)
+		SELECT $2, language, title, description
+		FROM book_translations
+		WHERE book_id = $1
+		ON CONFLICT (book_id, language) DO NOTHING`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		DELETE FROM book_translations
+		WHERE book_id = $1`, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE book_edit_suggestions
+		SET book_id = $2
+		WHERE book_id = $1 AND status = 'pending'`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE book_redirects
+		SET to_book_id = $2
+		WHERE to_book_id = $1`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO book_redirects (from_book_id, to_book_id)
+		VALUES ($1, $2)`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *bookRepository) FindRedirect(id string) (string, error) {
+	query := `
+		SELECT to_book_id
+		FROM book_redirects
+		WHERE from_book_id = $1`
+
+	var targetID string
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&targetID)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", common.ErrNotFound
+		default:
+			return "", err
+		}
+	}
+
+	return targetID, nil
+}
+
+func (r *bookRepository) FindTranslations(bookID string) ([]Translation, error) {
+	query := `
+		SELECT book_id, language, title, description, created_at, updated_at
+		FROM book_translations
+		WHERE book_id = $1
+		ORDER BY language`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []Translation{}
+
+	for rows.Next() {
+		var translation Translation
+
+		err := rows.Scan(&translation.BookID, &translation.Language, &translation.Title, &translation.Description, &translation.CreatedAt, &translation.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		translations = append(translations, translation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+func (r *bookRepository) SaveTranslation(translation *Translation) (*Translation, error) {
+	query := `
+		INSERT INTO book_translations (book_id, language, title, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (book_id, language)
+		DO UPDATE SET title = EXCLUDED.title, description = EXCLUDED.description
+		RETURNING created_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, translation.BookID, translation.Language, translation.Title, translation.Description).Scan(&translation.CreatedAt, &translation.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return translation, nil
+}
+
+func (r *bookRepository) DeleteTranslation(bookID, language string) error {
+	query := `
+		DELETE FROM book_translations
+		WHERE book_id = $1 AND language = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, bookID, language)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return common.ErrNotFound
+	}
+
+	return nil
+}
@@ -3,9 +3,13 @@ package book
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/tfidf"
+	"golang.org/x/text/language"
 )
 
 type BookService interface {
@@ -13,31 +17,60 @@ type BookService interface {
 	Create(book *CreateBookRequest) (*Book, error)
 	Update(id string, book *UpdateBookRequest) (*Book, error)
 	Delete(id string) error
+	Batch(ops []BatchOperation) ([]BatchResult, error)
+	Merge(sourceID, targetID string) (*Book, error)
+	Localize(book *Book, acceptLanguage string) (*Book, string, error)
+	ListTranslations(bookID string) ([]Translation, error)
+	PutTranslation(bookID, lang string, req *PutTranslationRequest) (*Translation, error)
+	DeleteTranslation(bookID, lang string) error
+	BuildSimilarityIndex() error
+	Similar(id string, limit int) ([]SimilarBook, error)
+	Reindex(book *Book)
 }
 
 type bookService struct {
-	repo BookRepository
+	repo    BookRepository
+	similar *tfidf.Index
 }
 
 func NewBookService(repo BookRepository) BookService {
 	return &bookService{
-		repo: repo,
+		repo:    repo,
+		similar: tfidf.NewIndex(),
 	}
 }
 
 func (s *bookService) Create(book *CreateBookRequest) (*Book, error) {
+	savedBook, err := create(s.repo, book)
+	if err != nil {
+		return nil, err
+	}
+
+	s.index(savedBook)
+
+	return savedBook, nil
+}
+
+func create(repo BookRepository, book *CreateBookRequest) (*Book, error) {
 
 	newId := uuid.New()
 
 	newBook := &Book{
-		ID:            newId,
-		Title:         book.Title,
-		Author:        book.Author,
-		PublishedYear: book.PublishedYear,
-		ISBN:          book.ISBN,
+		ID:              newId,
+		Title:           book.Title,
+		Subtitle:        book.Subtitle,
+		OriginalTitle:   book.OriginalTitle,
+		Author:          book.Author,
+		Description:     book.Description,
+		PublishedYear:   book.PublishedYear,
+		PublicationDate: parseDate(book.PublicationDate),
+		ISBN:            book.ISBN,
+		PageCount:       book.PageCount,
+		Language:        book.Language,
+		Format:          book.Format,
 	}
 
-	savedBook, err := s.repo.Save(newBook)
+	savedBook, err := repo.Save(newBook)
 
 	if err != nil {
 		return nil, err
@@ -53,6 +86,13 @@ func (s *bookService) GetBookById(id string) (*Book, error) {
 	if err != nil {
 		switch {
 		case errors.Is(err, common.ErrNotFound):
+			targetID, redirectErr := s.repo.FindRedirect(id)
+			if redirectErr == nil {
+				return nil, &MergedBookError{TargetID: targetID}
+			}
+			if !errors.Is(redirectErr, common.ErrNotFound) {
+				return nil, redirectErr
+			}
 			return nil, common.ErrNotFound
 
 		default:
@@ -65,8 +105,19 @@ func (s *bookService) GetBookById(id string) (*Book, error) {
 }
 
 func (s *bookService) Update(id string, updateReq *UpdateBookRequest) (*Book, error) {
+	book, err := update(s.repo, id, updateReq)
+	if err != nil {
+		return nil, err
+	}
+
+	s.index(book)
+
+	return book, nil
+}
+
+func update(repo BookRepository, id string, updateReq *UpdateBookRequest) (*Book, error) {
 
-	_, err := s.repo.FindById(id)
+	_, err := repo.FindById(id)
 	if err != nil {
 		switch {
 		case errors.Is(err, common.ErrNotFound):
@@ -77,14 +128,21 @@ func (s *bookService) Update(id string, updateReq *UpdateBookRequest) (*Book, er
 	}
 
 	updatedBook := &Book{
-		ID:            uuid.MustParse(id),
-		Title:         updateReq.Title,
-		Author:        updateReq.Author,
-		PublishedYear: updateReq.PublishedYear,
-		ISBN:          updateReq.ISBN,
+		ID:              uuid.MustParse(id),
+		Title:           updateReq.Title,
+		Subtitle:        updateReq.Subtitle,
+		OriginalTitle:   updateReq.OriginalTitle,
+		Author:          updateReq.Author,
+		Description:     updateReq.Description,
+		PublishedYear:   updateReq.PublishedYear,
+		PublicationDate: parseDate(updateReq.PublicationDate),
+		ISBN:            updateReq.ISBN,
+		PageCount:       updateReq.PageCount,
+		Language:        updateReq.Language,
+		Format:          updateReq.Format,
 	}
 
-	book, err := s.repo.Update(updatedBook)
+	book, err := repo.Update(updatedBook)
 
 	if err != nil {
 		switch {
@@ -100,8 +158,19 @@ func (s *bookService) Update(id string, updateReq *UpdateBookRequest) (*Book, er
 }
 
 func (s *bookService) Delete(id string) error {
+	err := remove(s.repo, id)
+	if err != nil {
+		return err
+	}
+
+	s.similar.Remove(id)
+
+	return nil
+}
+
+func remove(repo BookRepository, id string) error {
 
-	_, err := s.repo.FindById(id)
+	_, err := repo.FindById(id)
 	if err != nil {
 		switch {
 		case errors.Is(err, common.ErrNotFound):
@@ -113,12 +182,244 @@ func (s *bookService) Delete(id string) error {
 
 	}
 
-	err = s.repo.Delete(id)
+	err = repo.Delete(id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// Batch applies ops in order inside a single transaction. If any operation
+// fails, everything is rolled back and a *BatchError identifying the failing
+// operation is returned.
+func (s *bookService) Batch(ops []BatchOperation) ([]BatchResult, error) {
+	var results []BatchResult
+
+	err := s.repo.Transaction(func(repo BookRepository) error {
+		results = make([]BatchResult, 0, len(ops))
+
+		for i, op := range ops {
+			result := BatchResult{Op: op.Op}
+			var err error
+
+			switch op.Op {
+			case BatchOpCreate:
+				result.Book, err = create(repo, op.Create)
+			case BatchOpUpdate:
+				result.Book, err = update(repo, op.ID, op.Update)
+			case BatchOpDelete:
+				result.ID = op.ID
+				err = remove(repo, op.ID)
+			default:
+				err = fmt.Errorf("unsupported operation %q", op.Op)
+			}
+
+			if err != nil {
+				return &BatchError{Index: i, Op: op.Op, Err: err}
+			}
+
+			results = append(results, result)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The index is only touched once the transaction has been committed.
+	for _, result := range results {
+		switch result.Op {
+		case BatchOpCreate, BatchOpUpdate:
+			s.index(result.Book)
+		case BatchOpDelete:
+			s.similar.Remove(result.ID)
+		}
+	}
+
+	return results, nil
+}
+
+// Merge folds the source book into the target. The source is soft-deleted,
+// its translations move to the target and lookups of its ID are redirected to
+// the target from then on.
+func (s *bookService) Merge(sourceID, targetID string) (*Book, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeIntoSelf
+	}
+
+	var target *Book
+
+	err := s.repo.Transaction(func(repo BookRepository) error {
+		err := repo.Merge(sourceID, targetID)
+		if err != nil {
+			return err
+		}
+
+		target, err = repo.FindById(targetID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.similar.Remove(sourceID)
+
+	return target, nil
+}
+
+// Localize returns the variant of book that best matches the Accept-Language
+// header value, together with the language tag of that variant. The book's
+// own title and description are used when no translation matches.
+func (s *bookService) Localize(book *Book, acceptLanguage string) (*Book, string, error) {
+	translations, err := s.repo.FindTranslations(book.ID.String())
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(translations) == 0 {
+		return book, book.Language, nil
+	}
+
+	desired, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(desired) == 0 {
+		return book, book.Language, nil
+	}
+
+	// The original comes first so the matcher falls back to it.
+	original := language.Und
+	if book.Language != "" {
+		original = language.Make(book.Language)
+	}
+
+	supported := []language.Tag{original}
+	for _, translation := range translations {
+		supported = append(supported, language.Make(translation.Language))
+	}
+
+	_, index, confidence := language.NewMatcher(supported).Match(desired...)
+	if index == 0 || confidence == language.No {
+		return book, book.Language, nil
+	}
+
+	translation := translations[index-1]
+
+	localized := *book
+	localized.Title = translation.Title
+	if translation.Description != "" {
+		localized.Description = translation.Description
+	}
+
+	return &localized, translation.Language, nil
+}
 
+func (s *bookService) ListTranslations(bookID string) ([]Translation, error) {
+	_, err := s.GetBookById(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindTranslations(bookID)
+}
+
+func (s *bookService) PutTranslation(bookID, lang string, req *PutTranslationRequest) (*Translation, error) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return nil, ErrInvalidLanguage
+	}
+
+	book, err := s.GetBookById(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	translation := &Translation{
+		BookID:      book.ID,
+		Language:    tag.String(),
+		Title:       req.Title,
+		Description: req.Description,
+	}
+
+	return s.repo.SaveTranslation(translation)
+}
+
+func (s *bookService) DeleteTranslation(bookID, lang string) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return ErrInvalidLanguage
+	}
+
+	_, err = s.GetBookById(bookID)
 	if err != nil {
 		return err
 	}
 
+	return s.repo.DeleteTranslation(bookID, tag.String())
+}
+
+// BuildSimilarityIndex indexes every book for Similar. It is meant to be
+// called once at startup; afterwards the index is kept up to date as books
+// are created, updated and deleted through the service.
+func (s *bookService) BuildSimilarityIndex() error {
+	books, err := s.repo.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range books {
+		s.index(&books[i])
+	}
+
 	return nil
+}
+
+// Similar returns up to limit books whose title, description and author are
+// most similar to those of the given book, by cosine similarity of their
+// TF-IDF vectors.
+func (s *bookService) Similar(id string, limit int) ([]SimilarBook, error) {
+	book, err := s.GetBookById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Books written by another instance of the service are indexed on demand.
+	if !s.similar.Contains(id) {
+		s.index(book)
+	}
+
+	similar := []SimilarBook{}
+
+	for _, match := range s.similar.Similar(id, limit) {
+		matched, err := s.repo.FindById(match.ID)
+
+		if err != nil {
+			switch {
+			case errors.Is(err, common.ErrNotFound):
+				s.similar.Remove(match.ID)
+				continue
+			default:
+				return nil, err
+			}
+		}
+
+		similar = append(similar, SimilarBook{Book: *matched, Score: match.Score})
+	}
+
+	return similar, nil
+}
+
+// Reindex updates the similarity index after a book was changed through a
+// repository of another package.
+func (s *bookService) Reindex(book *Book) {
+	s.index(book)
+}
 
+func (s *bookService) index(book *Book) {
+	text := strings.Join([]string{book.Title, book.Subtitle, book.OriginalTitle, book.Description, book.Author}, " ")
+	s.similar.Add(book.ID.String(), text)
 }
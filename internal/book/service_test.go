@@ -86,6 +86,7 @@ func TestGetBookByIdService(t *testing.T) {
 		bookID := uuid.New()
 
 		mockRepo.On("FindById", bookID.String()).Return((*Book)(nil), common.ErrNotFound)
+		mockRepo.On("FindRedirect", bookID.String()).Return("", common.ErrNotFound)
 
 		result, err := service.GetBookById(bookID.String())
 
@@ -98,6 +99,25 @@ func TestGetBookByIdService(t *testing.T) {
 
 	})
 
+	t.Run("Get book by id service: Book was merged", func(t *testing.T) {
+		bookID := uuid.New()
+		targetID := uuid.New()
+
+		mockRepo.On("FindById", bookID.String()).Return((*Book)(nil), common.ErrNotFound)
+		mockRepo.On("FindRedirect", bookID.String()).Return(targetID.String(), nil)
+
+		result, err := service.GetBookById(bookID.String())
+
+		require.Error(t, err)
+		assert.Nil(t, result)
+
+		var mergedErr *MergedBookError
+		require.ErrorAs(t, err, &mergedErr)
+		assert.Equal(t, targetID.String(), mergedErr.TargetID)
+
+		mockRepo.AssertExpectations(t)
+	})
+
 }
 
 func TestUpdateBookService(t *testing.T) {
@@ -208,3 +228,312 @@ func TestDeleteBookService(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestBatchBookService(t *testing.T) {
+
+	t.Run("Batch book service: Successfully apply all operations", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		bookID := uuid.New()
+
+		existingBook := &Book{
+			ID:            bookID,
+			Title:         "Original Title",
+			Author:        "Original Author",
+			PublishedYear: 2000,
+			ISBN:          "9780743273565",
+		}
+
+		createdBook := &Book{
+			ID:            uuid.New(),
+			Title:         "New Book",
+			Author:        "New Author",
+			PublishedYear: 2010,
+			ISBN:          "9780743273565",
+			CreatedAt:     time.Now(),
+		}
+
+		mockRepo.On("Transaction").Return(nil)
+		mockRepo.On("Save", mock.AnythingOfType("*book.Book")).Return(createdBook, nil)
+		mockRepo.On("FindById", bookID.String()).Return(existingBook, nil)
+		mockRepo.On("Delete", bookID.String()).Return(nil)
+
+		ops := []BatchOperation{
+			{Op: BatchOpCreate, Create: &CreateBookRequest{Title: "New Book", Author: "New Author", PublishedYear: 2010, ISBN: "9780743273565"}},
+			{Op: BatchOpDelete, ID: bookID.String()},
+		}
+
+		results, err := service.Batch(ops)
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, createdBook, results[0].Book)
+		assert.Equal(t, BatchOpDelete, results[1].Op)
+		assert.Equal(t, bookID.String(), results[1].ID)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Batch book service: Failing operation aborts the batch", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		missingID := uuid.New()
+
+		mockRepo.On("Transaction").Return(nil)
+		mockRepo.On("FindById", missingID.String()).Return((*Book)(nil), common.ErrNotFound)
+
+		ops := []BatchOperation{
+			{Op: BatchOpUpdate, ID: missingID.String(), Update: &UpdateBookRequest{Title: "Title", Author: "Author", PublishedYear: 2000, ISBN: "9780743273565"}},
+			{Op: BatchOpDelete, ID: uuid.New().String()},
+		}
+
+		results, err := service.Batch(ops)
+
+		require.Error(t, err)
+		assert.Nil(t, results)
+
+		var batchErr *BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 0, batchErr.Index)
+		assert.ErrorIs(t, err, common.ErrNotFound)
+
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
+
+func TestMergeBookService(t *testing.T) {
+
+	t.Run("Merge book service: Successfully merge a book", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		sourceID := uuid.New()
+		targetID := uuid.New()
+
+		target := &Book{ID: targetID, Title: "The Great Gatsby"}
+
+		mockRepo.On("Transaction").Return(nil)
+		mockRepo.On("Merge", sourceID.String(), targetID.String()).Return(nil)
+		mockRepo.On("FindById", targetID.String()).Return(target, nil)
+
+		result, err := service.Merge(sourceID.String(), targetID.String())
+
+		require.NoError(t, err)
+		assert.Equal(t, target, result)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Merge book service: Cannot merge a book into itself", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		bookID := uuid.New()
+
+		result, err := service.Merge(bookID.String(), bookID.String())
+
+		require.ErrorIs(t, err, ErrMergeIntoSelf)
+		assert.Nil(t, result)
+
+		mockRepo.AssertNotCalled(t, "Transaction")
+	})
+
+	t.Run("Merge book service: Source book not found", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		sourceID := uuid.New()
+		targetID := uuid.New()
+
+		mockRepo.On("Transaction").Return(nil)
+		mockRepo.On("Merge", sourceID.String(), targetID.String()).Return(common.ErrNotFound)
+
+		result, err := service.Merge(sourceID.String(), targetID.String())
+
+		require.ErrorIs(t, err, common.ErrNotFound)
+		assert.Nil(t, result)
+
+		mockRepo.AssertNotCalled(t, "FindById", mock.Anything)
+	})
+}
+
+func TestLocalizeBookService(t *testing.T) {
+	bookID := uuid.New()
+
+	book := &Book{
+		ID:          bookID,
+		Title:       "The Great Gatsby",
+		Description: "A portrait of the Jazz Age.",
+		Language:    "en",
+	}
+
+	translations := []Translation{
+		{BookID: bookID, Language: "de", Title: "Der große Gatsby", Description: "Ein Porträt des Jazz-Zeitalters."},
+		{BookID: bookID, Language: "fr", Title: "Gatsby le Magnifique"},
+	}
+
+	t.Run("Localize book service: Pick the best matching translation", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		mockRepo.On("FindTranslations", bookID.String()).Return(translations, nil)
+
+		result, lang, err := service.Localize(book, "de-CH, fr;q=0.8")
+
+		require.NoError(t, err)
+		assert.Equal(t, "de", lang)
+		assert.Equal(t, "Der große Gatsby", result.Title)
+		assert.Equal(t, "Ein Porträt des Jazz-Zeitalters.", result.Description)
+		assert.Equal(t, "The Great Gatsby", book.Title)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Localize book service: Keep original description when translation has none", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		mockRepo.On("FindTranslations", bookID.String()).Return(translations, nil)
+
+		result, lang, err := service.Localize(book, "fr-FR")
+
+		require.NoError(t, err)
+		assert.Equal(t, "fr", lang)
+		assert.Equal(t, "Gatsby le Magnifique", result.Title)
+		assert.Equal(t, book.Description, result.Description)
+	})
+
+	t.Run("Localize book service: Fall back to the original", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		mockRepo.On("FindTranslations", bookID.String()).Return(translations, nil)
+
+		result, lang, err := service.Localize(book, "ja")
+
+		require.NoError(t, err)
+		assert.Equal(t, "en", lang)
+		assert.Equal(t, book, result)
+	})
+}
+
+func TestPutTranslationService(t *testing.T) {
+
+	t.Run("Put translation service: Language tag is canonicalized", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		bookID := uuid.New()
+
+		mockRepo.On("FindById", bookID.String()).Return(&Book{ID: bookID}, nil)
+		mockRepo.On("SaveTranslation", mock.MatchedBy(func(translation *Translation) bool {
+			return translation.Language == "pt-BR" && translation.BookID == bookID
+		})).Return(&Translation{BookID: bookID, Language: "pt-BR", Title: "O Grande Gatsby"}, nil)
+
+		result, err := service.PutTranslation(bookID.String(), "pt-br", &PutTranslationRequest{Title: "O Grande Gatsby"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "pt-BR", result.Language)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Put translation service: Invalid language", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		result, err := service.PutTranslation(uuid.New().String(), "not a language", &PutTranslationRequest{Title: "Title"})
+
+		require.ErrorIs(t, err, ErrInvalidLanguage)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Put translation service: Book was merged", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		bookID := uuid.New()
+		targetID := uuid.New()
+
+		mockRepo.On("FindById", bookID.String()).Return((*Book)(nil), common.ErrNotFound)
+		mockRepo.On("FindRedirect", bookID.String()).Return(targetID.String(), nil)
+
+		result, err := service.PutTranslation(bookID.String(), "de", &PutTranslationRequest{Title: "Der große Gatsby"})
+
+		var mergedErr *MergedBookError
+		require.ErrorAs(t, err, &mergedErr)
+		assert.Equal(t, targetID.String(), mergedErr.TargetID)
+		assert.Nil(t, result)
+
+		mockRepo.AssertNotCalled(t, "SaveTranslation", mock.Anything)
+	})
+}
+
+func TestSimilarBooksService(t *testing.T) {
+	gatsby := Book{ID: uuid.New(), Title: "The Great Gatsby", Author: "F. Scott Fitzgerald", Description: "A portrait of the Jazz Age, wealth and excess on Long Island."}
+	tender := Book{ID: uuid.New(), Title: "Tender Is the Night", Author: "F. Scott Fitzgerald", Description: "Wealth and excess on the French Riviera in the Jazz Age."}
+	moby := Book{ID: uuid.New(), Title: "Moby-Dick", Author: "Herman Melville", Description: "The voyage of the whaling ship Pequod."}
+
+	t.Run("Similar books service: Most similar books first", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		mockRepo.On("FindAll").Return([]Book{gatsby, tender, moby}, nil)
+		mockRepo.On("FindById", gatsby.ID.String()).Return(&gatsby, nil)
+		mockRepo.On("FindById", tender.ID.String()).Return(&tender, nil)
+
+		require.NoError(t, service.BuildSimilarityIndex())
+
+		result, err := service.Similar(gatsby.ID.String(), 10)
+
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, tender, result[0].Book)
+		assert.Greater(t, result[0].Score, 0.0)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Similar books service: Index follows updates and deletes", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		mockRepo.On("FindAll").Return([]Book{gatsby, tender, moby}, nil)
+		mockRepo.On("FindById", gatsby.ID.String()).Return(&gatsby, nil)
+		mockRepo.On("FindById", tender.ID.String()).Return(&tender, nil)
+		mockRepo.On("FindById", moby.ID.String()).Return(&moby, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*book.Book")).Return(&Book{ID: moby.ID, Title: "The Jazz Age on Long Island", Author: "Unknown"}, nil)
+		mockRepo.On("Delete", tender.ID.String()).Return(nil)
+
+		require.NoError(t, service.BuildSimilarityIndex())
+
+		_, err := service.Update(moby.ID.String(), &UpdateBookRequest{Title: "The Jazz Age on Long Island", Author: "Unknown"})
+		require.NoError(t, err)
+
+		require.NoError(t, service.Delete(tender.ID.String()))
+
+		result, err := service.Similar(gatsby.ID.String(), 10)
+
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, moby.ID, result[0].Book.ID)
+	})
+
+	t.Run("Similar books service: Book not found", func(t *testing.T) {
+		mockRepo := new(MockBookRepository)
+		service := NewBookService(mockRepo)
+
+		bookID := uuid.New()
+
+		mockRepo.On("FindById", bookID.String()).Return((*Book)(nil), common.ErrNotFound)
+		mockRepo.On("FindRedirect", bookID.String()).Return("", common.ErrNotFound)
+
+		result, err := service.Similar(bookID.String(), 10)
+
+		require.ErrorIs(t, err, common.ErrNotFound)
+		assert.Nil(t, result)
+	})
+}
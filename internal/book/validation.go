@@ -0,0 +1,37 @@
+package book
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns a validator with the book specific rules registered.
+func NewValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	_ = validate.RegisterValidation("notfuture", notFuture)
+
+	return validate
+}
+
+// notFuture accepts years (integers) and dates (DateLayout strings) that are
+// not later than today.
+func notFuture(fl validator.FieldLevel) bool {
+	now := time.Now()
+	field := fl.Field()
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int() <= int64(now.Year())
+	case reflect.String:
+		date, err := time.Parse(DateLayout, field.String())
+		if err != nil {
+			return false
+		}
+		return !date.After(now)
+	default:
+		return false
+	}
+}
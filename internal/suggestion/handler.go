@@ -0,0 +1,296 @@
+package suggestion
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jakottelaar/gobookreviewapp/internal/auth"
+	"github.com/jakottelaar/gobookreviewapp/internal/book"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/contentfilter"
+)
+
+type SuggestionHandler struct {
+	service SuggestionService
+}
+
+func NewSuggestionHandler(service SuggestionService) *SuggestionHandler {
+	return &SuggestionHandler{
+		service: service,
+	}
+}
+
+// SubmitSuggestion godoc
+// @Summary Suggest changes to a book
+// @Description Submit proposed changes to the metadata of a book for moderator review
+// @Tags suggestions
+// @Accept json
+// @Produce json
+// @Param id path string true "Book ID" format(uuid)
+// @Param suggestion body SubmitSuggestionRequest true "Suggested changes"
+// @Success 201 {object} SuggestionResponse
+// @Security BearerAuth
+// @Router /books/{id}/suggestions [post]
+func (h *SuggestionHandler) SubmitSuggestion(w http.ResponseWriter, r *http.Request) {
+	bookID, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var req SubmitSuggestionRequest
+
+	err = common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	u, _ := auth.ContextGetUser(r)
+
+	suggestion, err := h.service.Submit(bookID, u.ID.String(), &req)
+
+	if err != nil {
+		var rejectedErr *contentfilter.RejectedError
+
+		switch {
+		case errors.As(err, &rejectedErr):
+			common.FailedValidationResponse(w, r, rejectedErr.Fields)
+		case errors.Is(err, ErrNoChanges):
+			common.FailedValidationResponse(w, r, map[string]string{"Changes": err.Error()})
+		case errors.Is(err, common.ErrNotFound):
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusCreated, common.Envelope{"suggestion": newSuggestionResponse(suggestion)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ListSuggestions godoc
+// @Summary List edit suggestions
+// @Description List edit suggestions by status, oldest first. Defaults to the pending moderation queue.
+// @Tags suggestions
+// @Accept json
+// @Produce json
+// @Param status query string false "Suggestion status" Enums(pending, approved, rejected)
+// @Success 200 {array} SuggestionResponse
+// @Security BearerAuth
+// @Router /suggestions [get]
+func (h *SuggestionHandler) ListSuggestions(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+
+	switch status {
+	case "":
+		status = StatusPending
+	case StatusPending, StatusApproved, StatusRejected:
+	default:
+		common.FailedValidationResponse(w, r, map[string]string{"status": "oneof"})
+		return
+	}
+
+	suggestions, err := h.service.List(status)
+
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	resp := make([]SuggestionResponse, len(suggestions))
+	for i := range suggestions {
+		resp[i] = newSuggestionResponse(&suggestions[i])
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"suggestions": resp}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ListOwnSuggestions godoc
+// @Summary List your edit suggestions
+// @Description List the edit suggestions submitted by the current user, oldest first, with the outcome of their review
+// @Tags suggestions
+// @Produce json
+// @Success 200 {array} SuggestionResponse
+// @Security BearerAuth
+// @Router /me/suggestions [get]
+func (h *SuggestionHandler) ListOwnSuggestions(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.ContextGetUser(r)
+
+	suggestions, err := h.service.ListBySubmitter(u.ID.String())
+
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	resp := make([]SuggestionResponse, len(suggestions))
+	for i := range suggestions {
+		resp[i] = newSuggestionResponse(&suggestions[i])
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"suggestions": resp}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// GetSuggestionById godoc
+// @Summary Get an edit suggestion by ID
+// @Description Get an edit suggestion together with a diff against the current book
+// @Tags suggestions
+// @Accept json
+// @Produce json
+// @Param id path string true "Suggestion ID" format(uuid)
+// @Success 200 {object} SuggestionResponse
+// @Security BearerAuth
+// @Router /suggestions/{id} [get]
+func (h *SuggestionHandler) GetSuggestionById(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	suggestion, err := h.service.GetById(id)
+
+	if err != nil {
+		switch err {
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	diff, err := h.service.Diff(suggestion)
+
+	if err != nil {
+		switch err {
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"suggestion": newSuggestionResponse(suggestion), "diff": diff}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// ApproveSuggestion godoc
+// @Summary Approve an edit suggestion
+// @Description Apply the suggested changes to the book and record the current user as reviewer
+// @Tags suggestions
+// @Accept json
+// @Produce json
+// @Param id path string true "Suggestion ID" format(uuid)
+// @Param review body ReviewSuggestionRequest true "Review details"
+// @Success 200 {object} SuggestionResponse
+// @Security BearerAuth
+// @Router /suggestions/{id}/approve [post]
+func (h *SuggestionHandler) ApproveSuggestion(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.Approve)
+}
+
+// RejectSuggestion godoc
+// @Summary Reject an edit suggestion
+// @Description Reject the suggested changes and record the current user as reviewer, with the reason
+// @Tags suggestions
+// @Accept json
+// @Produce json
+// @Param id path string true "Suggestion ID" format(uuid)
+// @Param review body ReviewSuggestionRequest true "Review details"
+// @Success 200 {object} SuggestionResponse
+// @Security BearerAuth
+// @Router /suggestions/{id}/reject [post]
+func (h *SuggestionHandler) RejectSuggestion(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.Reject)
+}
+
+func (h *SuggestionHandler) review(w http.ResponseWriter, r *http.Request, action func(id, reviewedBy string, req *ReviewSuggestionRequest) (*Suggestion, error)) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var req ReviewSuggestionRequest
+
+	err = common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	u, _ := auth.ContextGetUser(r)
+
+	suggestion, err := action(id, u.ID.String(), &req)
+
+	if err != nil {
+		switch err {
+		case ErrAlreadyReviewed:
+			common.ConflictResponse(w, r, err)
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"suggestion": newSuggestionResponse(suggestion)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// validateRequest validates req with the book validation rules and writes a
+// failed validation response if it is invalid.
+func validateRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	validate := book.NewValidator()
+
+	err := validate.Struct(req)
+
+	if err != nil {
+		errors := make(map[string]string)
+
+		for _, err := range err.(validator.ValidationErrors) {
+			errors[err.Field()] = err.Tag()
+		}
+
+		common.FailedValidationResponse(w, r, errors)
+		return false
+	}
+
+	return true
+}
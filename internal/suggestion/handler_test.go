@@ -0,0 +1,217 @@
+//go:build unit
+// +build unit
+
+package suggestion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/auth"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitSuggestionHandler(t *testing.T) {
+	mockService := new(MockSuggestionService)
+	handler := NewSuggestionHandler(mockService)
+
+	t.Run("POST Suggestion handler: Successfully submit a suggestion", func(t *testing.T) {
+		bookID := uuid.New()
+		submitter := &user.User{ID: uuid.New(), Role: user.RoleReader}
+
+		expected := &Suggestion{
+			ID:          uuid.New(),
+			BookID:      bookID,
+			Changes:     Changes{Title: stringPtr("The Great Gatsby")},
+			Status:      StatusPending,
+			SubmittedBy: submitter.ID.String(),
+			CreatedAt:   time.Now(),
+		}
+
+		mockService.On("Submit", bookID.String(), submitter.ID.String(), mock.AnythingOfType("*suggestion.SubmitSuggestionRequest")).Return(expected, nil)
+
+		reqBody := `{"changes": {"title": "The Great Gatsby"}}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/books/"+bookID.String()+"/suggestions", bytes.NewReader([]byte(reqBody)))
+		req.Header.Set("Content-Type", "application/json")
+		req = auth.ContextSetUser(req, submitter)
+
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Post("/v1/api/books/{id}/suggestions", handler.SubmitSuggestion)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		suggestion, ok := response["suggestion"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, expected.ID.String(), suggestion["id"])
+		assert.Equal(t, "pending", suggestion["status"])
+		assert.Equal(t, map[string]interface{}{"title": "The Great Gatsby"}, suggestion["changes"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Suggestion handler: Invalid changes", func(t *testing.T) {
+		bookID := uuid.New()
+
+		reqBody := `{"changes": {"title": "", "page_count": -1, "language": "not a language"}}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/books/"+bookID.String()+"/suggestions", bytes.NewReader([]byte(reqBody)))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Post("/v1/api/books/{id}/suggestions", handler.SubmitSuggestion)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Contains(t, response["error"], "Title")
+		assert.Contains(t, response["error"], "PageCount")
+		assert.Contains(t, response["error"], "Language")
+	})
+}
+
+func TestListOwnSuggestionsHandler(t *testing.T) {
+	mockService := new(MockSuggestionService)
+	handler := NewSuggestionHandler(mockService)
+
+	t.Run("GET Own suggestions handler: Only the suggestions of the current user", func(t *testing.T) {
+		submitter := &user.User{ID: uuid.New(), Role: user.RoleReader}
+
+		mockService.On("ListBySubmitter", submitter.ID.String()).Return([]Suggestion{
+			{ID: uuid.New(), BookID: uuid.New(), Status: StatusRejected, SubmittedBy: submitter.ID.String(), ReviewComment: "Page count is correct"},
+		}, nil)
+
+		req := auth.ContextSetUser(httptest.NewRequest(http.MethodGet, "/v1/api/me/suggestions", nil), submitter)
+		w := httptest.NewRecorder()
+
+		handler.ListOwnSuggestions(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string][]map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.Len(t, response["suggestions"], 1)
+		assert.Equal(t, StatusRejected, response["suggestions"][0]["status"])
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetSuggestionHandler(t *testing.T) {
+	mockService := new(MockSuggestionService)
+	handler := NewSuggestionHandler(mockService)
+
+	t.Run("GET Suggestion by id handler: Includes diff", func(t *testing.T) {
+		suggestion := &Suggestion{
+			ID:      uuid.New(),
+			BookID:  uuid.New(),
+			Changes: Changes{Title: stringPtr("The Great Gatsby")},
+			Status:  StatusPending,
+		}
+
+		diff := []FieldDiff{{Field: "title", Current: "The Grate Gatsby", Proposed: "The Great Gatsby"}}
+
+		mockService.On("GetById", suggestion.ID.String()).Return(suggestion, nil)
+		mockService.On("Diff", suggestion).Return(diff, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/api/suggestions/"+suggestion.ID.String(), nil)
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Get("/v1/api/suggestions/{id}", handler.GetSuggestionById)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		respDiff, ok := response["diff"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, respDiff, 1)
+		assert.Equal(t, "The Grate Gatsby", respDiff[0].(map[string]interface{})["current"])
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestReviewSuggestionHandler(t *testing.T) {
+	mockService := new(MockSuggestionService)
+	handler := NewSuggestionHandler(mockService)
+
+	moderator := &user.User{ID: uuid.New(), Role: user.RoleModerator}
+
+	t.Run("POST Approve suggestion handler: Already reviewed", func(t *testing.T) {
+		suggestionID := uuid.New()
+
+		mockService.On("Approve", suggestionID.String(), moderator.ID.String(), mock.AnythingOfType("*suggestion.ReviewSuggestionRequest")).Return((*Suggestion)(nil), ErrAlreadyReviewed)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/suggestions/"+suggestionID.String()+"/approve", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req = auth.ContextSetUser(req, moderator)
+
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Post("/v1/api/suggestions/{id}/approve", handler.ApproveSuggestion)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Reject suggestion handler: Successfully reject a suggestion", func(t *testing.T) {
+		suggestionID := uuid.New()
+
+		rejected := &Suggestion{ID: suggestionID, BookID: uuid.New(), Status: StatusRejected, ReviewedBy: moderator.ID.String(), ReviewComment: "Wrong edition"}
+
+		mockService.On("Reject", suggestionID.String(), moderator.ID.String(), mock.AnythingOfType("*suggestion.ReviewSuggestionRequest")).Return(rejected, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/api/suggestions/"+suggestionID.String()+"/reject", bytes.NewReader([]byte(`{"comment": "Wrong edition"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req = auth.ContextSetUser(req, moderator)
+
+		w := httptest.NewRecorder()
+
+		r := chi.NewRouter()
+		r.Post("/v1/api/suggestions/{id}/reject", handler.RejectSuggestion)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		suggestion, ok := response["suggestion"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "rejected", suggestion["status"])
+		assert.Equal(t, "Wrong edition", suggestion["review_comment"])
+
+		mockService.AssertExpectations(t)
+	})
+}
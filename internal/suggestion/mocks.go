@@ -0,0 +1,99 @@
+package suggestion
+
+import (
+	"github.com/jakottelaar/gobookreviewapp/internal/book"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSuggestionRepository struct {
+	mock.Mock
+}
+
+type MockSuggestionService struct {
+	mock.Mock
+}
+
+func (m *MockSuggestionService) Submit(bookID, submittedBy string, req *SubmitSuggestionRequest) (*Suggestion, error) {
+	args := m.Called(bookID, submittedBy, req)
+	return args.Get(0).(*Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionService) GetById(id string) (*Suggestion, error) {
+	args := m.Called(id)
+	return args.Get(0).(*Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionService) Diff(suggestion *Suggestion) ([]FieldDiff, error) {
+	args := m.Called(suggestion)
+	return args.Get(0).([]FieldDiff), args.Error(1)
+}
+
+func (m *MockSuggestionService) List(status string) ([]Suggestion, error) {
+	args := m.Called(status)
+	return args.Get(0).([]Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionService) Approve(id, reviewedBy string, req *ReviewSuggestionRequest) (*Suggestion, error) {
+	args := m.Called(id, reviewedBy, req)
+	return args.Get(0).(*Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionService) Reject(id, reviewedBy string, req *ReviewSuggestionRequest) (*Suggestion, error) {
+	args := m.Called(id, reviewedBy, req)
+	return args.Get(0).(*Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionRepository) Save(suggestion *Suggestion) (*Suggestion, error) {
+	args := m.Called(suggestion)
+	return args.Get(0).(*Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionRepository) FindById(id string) (*Suggestion, error) {
+	args := m.Called(id)
+	return args.Get(0).(*Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionRepository) FindByStatus(status string) ([]Suggestion, error) {
+	args := m.Called(status)
+	return args.Get(0).([]Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionRepository) UpdateReview(suggestion *Suggestion) (*Suggestion, error) {
+	args := m.Called(suggestion)
+	return args.Get(0).(*Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionService) ListBySubmitter(userID string) ([]Suggestion, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionService) ListByReviewer(userID string) ([]Suggestion, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionRepository) FindBySubmitter(userID string) ([]Suggestion, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionRepository) FindByReviewer(userID string) ([]Suggestion, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]Suggestion), args.Error(1)
+}
+
+func (m *MockSuggestionRepository) Books() book.BookRepository {
+	args := m.Called()
+	return args.Get(0).(book.BookRepository)
+}
+
+// Transaction runs fn against the mock itself so expectations set on the
+// repository also apply to calls made inside the transaction.
+func (m *MockSuggestionRepository) Transaction(fn func(repo SuggestionRepository) error) error {
+	args := m.Called()
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
@@ -0,0 +1,221 @@
+package suggestion
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/internal/book"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/database"
+	"github.com/lib/pq"
+)
+
+type SuggestionRepository interface {
+	Save(suggestion *Suggestion) (*Suggestion, error)
+	FindById(id string) (*Suggestion, error)
+	FindByStatus(status string) ([]Suggestion, error)
+	UpdateReview(suggestion *Suggestion) (*Suggestion, error)
+	FindBySubmitter(userID string) ([]Suggestion, error)
+	FindByReviewer(userID string) ([]Suggestion, error)
+	Books() book.BookRepository
+	Transaction(fn func(repo SuggestionRepository) error) error
+}
+
+type suggestionRepository struct {
+	db   database.DBTX
+	conn *sql.DB
+}
+
+func NewSuggestionRepository(db *sql.DB) SuggestionRepository {
+	return &suggestionRepository{
+		db:   db,
+		conn: db,
+	}
+}
+
+// Books returns a book repository that runs in the same transaction as r.
+func (r *suggestionRepository) Books() book.BookRepository {
+	return book.NewTxBookRepository(r.db)
+}
+
+func (r *suggestionRepository) Transaction(fn func(repo SuggestionRepository) error) error {
+	if r.conn == nil {
+		return errors.New("transaction already in progress")
+	}
+
+	return database.Transaction(context.Background(), r.conn, func(tx *sql.Tx) error {
+		return fn(&suggestionRepository{db: tx})
+	})
+}
+
+func (r *suggestionRepository) Save(suggestion *Suggestion) (*Suggestion, error) {
+	query := `
+		INSERT INTO book_edit_suggestions (id, book_id, changes, comment, status, submitted_by, flags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at`
+
+	changes, err := json.Marshal(suggestion.Changes)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := suggestion.Flags
+	if flags == nil {
+		flags = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = r.db.QueryRowContext(ctx, query, suggestion.ID, suggestion.BookID, changes, suggestion.Comment, suggestion.Status, suggestion.SubmittedBy, pq.Array(flags)).Scan(&suggestion.CreatedAt, &suggestion.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestion, nil
+}
+
+func (r *suggestionRepository) FindById(id string) (*Suggestion, error) {
+	query := `
+		SELECT id, book_id, changes, comment, status, submitted_by, reviewed_by, review_comment, reviewed_at, flags, created_at, updated_at
+		FROM book_edit_suggestions
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	suggestion, err := scanSuggestion(r.db.QueryRowContext(ctx, query, id))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, common.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return suggestion, nil
+}
+
+func (r *suggestionRepository) FindByStatus(status string) ([]Suggestion, error) {
+	query := `
+		SELECT id, book_id, changes, comment, status, submitted_by, reviewed_by, review_comment, reviewed_at, flags, created_at, updated_at
+		FROM book_edit_suggestions
+		WHERE status = $1
+		ORDER BY created_at
+		LIMIT 100`
+
+	return r.findMany(query, status)
+}
+
+func (r *suggestionRepository) FindBySubmitter(userID string) ([]Suggestion, error) {
+	query := `
+		SELECT id, book_id, changes, comment, status, submitted_by, reviewed_by, review_comment, reviewed_at, flags, created_at, updated_at
+		FROM book_edit_suggestions
+		WHERE submitted_by = $1
+		ORDER BY created_at`
+
+	return r.findMany(query, userID)
+}
+
+func (r *suggestionRepository) FindByReviewer(userID string) ([]Suggestion, error) {
+	query := `
+		SELECT id, book_id, changes, comment, status, submitted_by, reviewed_by, review_comment, reviewed_at, flags, created_at, updated_at
+		FROM book_edit_suggestions
+		WHERE reviewed_by = $1
+		ORDER BY reviewed_at`
+
+	return r.findMany(query, userID)
+}
+
+func (r *suggestionRepository) findMany(query string, args ...any) ([]Suggestion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []Suggestion{}
+
+	for rows.Next() {
+		suggestion, err := scanSuggestion(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, *suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// UpdateReview yields ErrAlreadyReviewed for suggestions that are no longer
+// pending.
+func (r *suggestionRepository) UpdateReview(suggestion *Suggestion) (*Suggestion, error) {
+	query := `
+		UPDATE book_edit_suggestions
+		SET status = $1, reviewed_by = $2, review_comment = $3, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND status = 'pending'
+		RETURNING reviewed_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, suggestion.Status, suggestion.ReviewedBy, suggestion.ReviewComment, suggestion.ID).Scan(&suggestion.ReviewedAt, &suggestion.UpdatedAt)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrAlreadyReviewed
+		default:
+			return nil, err
+		}
+	}
+
+	return suggestion, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSuggestion(row scanner) (*Suggestion, error) {
+	var suggestion Suggestion
+	var changes []byte
+
+	err := row.Scan(
+		&suggestion.ID,
+		&suggestion.BookID,
+		&changes,
+		&suggestion.Comment,
+		&suggestion.Status,
+		&suggestion.SubmittedBy,
+		&suggestion.ReviewedBy,
+		&suggestion.ReviewComment,
+		&suggestion.ReviewedAt,
+		pq.Array(&suggestion.Flags),
+		&suggestion.CreatedAt,
+		&suggestion.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(changes, &suggestion.Changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &suggestion, nil
+}
@@ -0,0 +1,232 @@
+package suggestion
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/book"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/contentfilter"
+)
+
+type SuggestionService interface {
+	Submit(bookID, submittedBy string, req *SubmitSuggestionRequest) (*Suggestion, error)
+	GetById(id string) (*Suggestion, error)
+	Diff(suggestion *Suggestion) ([]FieldDiff, error)
+	List(status string) ([]Suggestion, error)
+	Approve(id, reviewedBy string, req *ReviewSuggestionRequest) (*Suggestion, error)
+	Reject(id, reviewedBy string, req *ReviewSuggestionRequest) (*Suggestion, error)
+	ListBySubmitter(userID string) ([]Suggestion, error)
+	ListByReviewer(userID string) ([]Suggestion, error)
+}
+
+type suggestionService struct {
+	repo   SuggestionRepository
+	books  book.BookService
+	filter contentfilter.ContentFilter
+}
+
+func NewSuggestionService(repo SuggestionRepository, books book.BookService, filter contentfilter.ContentFilter) SuggestionService {
+	return &suggestionService{
+		repo:   repo,
+		books:  books,
+		filter: filter,
+	}
+}
+
+// Submit records the suggested changes of the user submittedBy for review.
+func (s *suggestionService) Submit(bookID, submittedBy string, req *SubmitSuggestionRequest) (*Suggestion, error) {
+	if req.Changes.IsEmpty() {
+		return nil, ErrNoChanges
+	}
+
+	suggestion := &Suggestion{
+		ID:          uuid.New(),
+		Changes:     req.Changes,
+		Comment:     req.Comment,
+		Status:      StatusPending,
+		SubmittedBy: submittedBy,
+	}
+
+	err := s.filterContent(suggestion)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.getBook(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion.BookID = current.ID
+
+	return s.repo.Save(suggestion)
+}
+
+// filterContent runs the free text of a suggestion through the content
+// filter. Masked text replaces the submitted text, masked and flagged fields
+// are recorded for the moderator, and any rejected field fails the submission.
+func (s *suggestionService) filterContent(suggestion *Suggestion) error {
+	fields := map[string]*string{
+		"Comment":       &suggestion.Comment,
+		"Title":         suggestion.Changes.Title,
+		"Subtitle":      suggestion.Changes.Subtitle,
+		"OriginalTitle": suggestion.Changes.OriginalTitle,
+		"Author":        suggestion.Changes.Author,
+		"Description":   suggestion.Changes.Description,
+	}
+
+	rejected := make(map[string]string)
+
+	for field, text := range fields {
+		if text == nil {
+			continue
+		}
+
+		result := s.filter.Check(*text)
+
+		switch result.Action {
+		case contentfilter.ActionReject:
+			rejected[field] = result.Reason()
+		case contentfilter.ActionMask:
+			masked := result.Text
+			fields[field] = &masked
+			suggestion.Flags = append(suggestion.Flags, field+": "+result.Reason())
+		case contentfilter.ActionFlag:
+			suggestion.Flags = append(suggestion.Flags, field+": "+result.Reason())
+		}
+	}
+
+	if len(rejected) > 0 {
+		return &contentfilter.RejectedError{Fields: rejected}
+	}
+
+	// Masked values were stored as new strings so the request is not modified.
+	suggestion.Comment = *fields["Comment"]
+	suggestion.Changes.Title = fields["Title"]
+	suggestion.Changes.Subtitle = fields["Subtitle"]
+	suggestion.Changes.OriginalTitle = fields["OriginalTitle"]
+	suggestion.Changes.Author = fields["Author"]
+	suggestion.Changes.Description = fields["Description"]
+
+	sort.Strings(suggestion.Flags)
+
+	return nil
+}
+
+func (s *suggestionService) GetById(id string) (*Suggestion, error) {
+	return s.repo.FindById(id)
+}
+
+// Diff compares the suggestion with the current state of its book.
+func (s *suggestionService) Diff(suggestion *Suggestion) ([]FieldDiff, error) {
+	current, err := s.getBook(suggestion.BookID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestion.Changes.Diff(current)
+}
+
+func (s *suggestionService) List(status string) ([]Suggestion, error) {
+	return s.repo.FindByStatus(status)
+}
+
+func (s *suggestionService) ListBySubmitter(userID string) ([]Suggestion, error) {
+	return s.repo.FindBySubmitter(userID)
+}
+
+func (s *suggestionService) ListByReviewer(userID string) ([]Suggestion, error) {
+	return s.repo.FindByReviewer(userID)
+}
+
+// Approve applies the suggested changes to the book and records who approved
+// them, in one transaction. The suggestion is claimed first, so a concurrent
+// review waits and then fails with ErrAlreadyReviewed. The book is locked and
+// read inside the transaction, so edits made since the suggestion was
+// submitted are kept.
+func (s *suggestionService) Approve(id, reviewedBy string, req *ReviewSuggestionRequest) (*Suggestion, error) {
+	suggestion, err := s.getPending(id)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion.Status = StatusApproved
+	suggestion.ReviewedBy = reviewedBy
+	suggestion.ReviewComment = req.Comment
+
+	var approved *Suggestion
+	var updated *book.Book
+
+	err = s.repo.Transaction(func(repo SuggestionRepository) error {
+		var err error
+
+		approved, err = repo.UpdateReview(suggestion)
+		if err != nil {
+			return err
+		}
+
+		books := repo.Books()
+
+		current, err := books.FindByIdForUpdate(suggestion.BookID.String())
+		if err != nil {
+			return err
+		}
+
+		updated, err = books.Update(suggestion.Changes.Apply(current))
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.books.Reindex(updated)
+
+	return approved, nil
+}
+
+func (s *suggestionService) Reject(id, reviewedBy string, req *ReviewSuggestionRequest) (*Suggestion, error) {
+	suggestion, err := s.getPending(id)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion.Status = StatusRejected
+	suggestion.ReviewedBy = reviewedBy
+	suggestion.ReviewComment = req.Comment
+
+	return s.repo.UpdateReview(suggestion)
+}
+
+func (s *suggestionService) getPending(id string) (*Suggestion, error) {
+	suggestion, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if suggestion.Status != StatusPending {
+		return nil, ErrAlreadyReviewed
+	}
+
+	return suggestion, nil
+}
+
+// getBook looks up a book, treating books that were merged away as missing.
+func (s *suggestionService) getBook(id string) (*book.Book, error) {
+	current, err := s.books.GetBookById(id)
+
+	if err != nil {
+		var mergedErr *book.MergedBookError
+
+		switch {
+		case errors.As(err, &mergedErr):
+			return nil, common.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return current, nil
+}
@@ -0,0 +1,273 @@
+//go:build unit
+// +build unit
+
+package suggestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/book"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/contentfilter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestSubmitSuggestionService(t *testing.T) {
+
+	t.Run("Submit suggestion service: Successfully submit a suggestion", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, contentfilter.NewWordlistFilter(nil))
+
+		bookID := uuid.New()
+		userID := uuid.New().String()
+
+		req := &SubmitSuggestionRequest{
+			Changes: Changes{Title: stringPtr("The Great Gatsby")},
+		}
+
+		mockBooks.On("GetBookById", bookID.String()).Return(&book.Book{ID: bookID, Title: "The Grate Gatsby"}, nil)
+		mockRepo.On("Save", mock.MatchedBy(func(s *Suggestion) bool {
+			return s.BookID == bookID && s.Status == StatusPending && s.SubmittedBy == userID
+		})).Return(&Suggestion{ID: uuid.New(), BookID: bookID, Status: StatusPending}, nil)
+
+		result, err := service.Submit(bookID.String(), userID, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusPending, result.Status)
+
+		mockRepo.AssertExpectations(t)
+		mockBooks.AssertExpectations(t)
+	})
+
+	t.Run("Submit suggestion service: No changes", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, contentfilter.NewWordlistFilter(nil))
+
+		result, err := service.Submit(uuid.New().String(), uuid.New().String(), &SubmitSuggestionRequest{})
+
+		require.ErrorIs(t, err, ErrNoChanges)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Submit suggestion service: Book was merged", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, contentfilter.NewWordlistFilter(nil))
+
+		bookID := uuid.New()
+
+		mockBooks.On("GetBookById", bookID.String()).Return((*book.Book)(nil), &book.MergedBookError{TargetID: uuid.New().String()})
+
+		result, err := service.Submit(bookID.String(), uuid.New().String(), &SubmitSuggestionRequest{Changes: Changes{PageCount: intPtr(180)}})
+
+		require.ErrorIs(t, err, common.ErrNotFound)
+		assert.Nil(t, result)
+	})
+}
+
+func TestSubmitSuggestionContentFilter(t *testing.T) {
+	filter := contentfilter.NewWordlistFilter([]contentfilter.Entry{
+		{Term: "idiot", Action: contentfilter.ActionMask, Category: "insult"},
+		{Term: "cheap pills", Action: contentfilter.ActionReject, Category: "spam"},
+		{Term: "boring", Action: contentfilter.ActionFlag, Category: "negativity"},
+	})
+
+	t.Run("Submit suggestion service: Rejected content", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, filter)
+
+		req := &SubmitSuggestionRequest{
+			Changes: Changes{Description: stringPtr("Buy CHEAP p1lls here")},
+		}
+
+		result, err := service.Submit(uuid.New().String(), uuid.New().String(), req)
+
+		require.Error(t, err)
+		assert.Nil(t, result)
+
+		var rejectedErr *contentfilter.RejectedError
+		require.ErrorAs(t, err, &rejectedErr)
+		assert.Equal(t, map[string]string{"Description": "violates content policy: spam"}, rejectedErr.Fields)
+
+		mockBooks.AssertNotCalled(t, "GetBookById", mock.Anything)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("Submit suggestion service: Masked and flagged content", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, filter)
+
+		bookID := uuid.New()
+
+		req := &SubmitSuggestionRequest{
+			Changes: Changes{Title: stringPtr("The Great Gatsby")},
+			Comment: "Whoever typed this is an idiot. Boring book anyway.",
+		}
+
+		mockBooks.On("GetBookById", bookID.String()).Return(&book.Book{ID: bookID}, nil)
+		mockRepo.On("Save", mock.MatchedBy(func(s *Suggestion) bool {
+			return s.Comment == "Whoever typed this is an *****. Boring book anyway." &&
+				assert.ObjectsAreEqual([]string{"Comment: violates content policy: insult, negativity"}, s.Flags)
+		})).Return(&Suggestion{ID: uuid.New(), BookID: bookID}, nil)
+
+		_, err := service.Submit(bookID.String(), uuid.New().String(), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Whoever typed this is an idiot. Boring book anyway.", req.Comment)
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestApproveSuggestionService(t *testing.T) {
+
+	t.Run("Approve suggestion service: Changes are applied to the book", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, contentfilter.NewWordlistFilter(nil))
+
+		bookID := uuid.New()
+		suggestionID := uuid.New()
+		moderatorID := uuid.New().String()
+
+		current := &book.Book{
+			ID:            bookID,
+			Title:         "The Grate Gatsby",
+			Author:        "F. Scott Fitzgerald",
+			PublishedYear: 1925,
+			ISBN:          "9780743273565",
+			Language:      "en",
+		}
+
+		suggestion := &Suggestion{
+			ID:          suggestionID,
+			BookID:      bookID,
+			Changes:     Changes{Title: stringPtr("The Great Gatsby"), PageCount: intPtr(180)},
+			Status:      StatusPending,
+			SubmittedBy: "jane.doe",
+		}
+
+		mockBookRepo := new(book.MockBookRepository)
+
+		mockRepo.On("FindById", suggestionID.String()).Return(suggestion, nil)
+		mockRepo.On("Transaction").Return(nil)
+		mockRepo.On("UpdateReview", mock.MatchedBy(func(s *Suggestion) bool {
+			return s.Status == StatusApproved && s.ReviewedBy == moderatorID
+		})).Return(suggestion, nil)
+		mockRepo.On("Books").Return(mockBookRepo)
+		mockBookRepo.On("FindByIdForUpdate", bookID.String()).Return(current, nil)
+		mockBookRepo.On("Update", mock.MatchedBy(func(b *book.Book) bool {
+			return b.ID == bookID && b.Title == "The Great Gatsby" && b.PageCount == 180 && b.Author == current.Author && b.Language == "en"
+		})).Return(current, nil)
+		mockBooks.On("Reindex", current).Return()
+
+		result, err := service.Approve(suggestionID.String(), moderatorID, &ReviewSuggestionRequest{})
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusApproved, result.Status)
+
+		mockRepo.AssertExpectations(t)
+		mockBookRepo.AssertExpectations(t)
+		mockBooks.AssertExpectations(t)
+	})
+
+	t.Run("Approve suggestion service: Book was edited after the suggestion was submitted", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBookRepo := new(book.MockBookRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, contentfilter.NewWordlistFilter(nil))
+
+		bookID := uuid.New()
+		suggestionID := uuid.New()
+
+		// The description and page count were edited after the title was suggested
+		edited := &book.Book{ID: bookID, Title: "The Grate Gatsby", Description: "A portrait of the Jazz Age.", PageCount: 208}
+
+		mockRepo.On("FindById", suggestionID.String()).Return(&Suggestion{ID: suggestionID, BookID: bookID, Changes: Changes{Title: stringPtr("The Great Gatsby")}, Status: StatusPending}, nil)
+		mockRepo.On("Transaction").Return(nil)
+		mockRepo.On("UpdateReview", mock.Anything).Return(&Suggestion{ID: suggestionID, Status: StatusApproved}, nil)
+		mockRepo.On("Books").Return(mockBookRepo)
+		mockBookRepo.On("FindByIdForUpdate", bookID.String()).Return(edited, nil)
+		mockBookRepo.On("Update", &book.Book{ID: bookID, Title: "The Great Gatsby", Description: "A portrait of the Jazz Age.", PageCount: 208}).Return(edited, nil).Once()
+		mockBooks.On("Reindex", mock.Anything).Return()
+
+		_, err := service.Approve(suggestionID.String(), uuid.New().String(), &ReviewSuggestionRequest{})
+
+		require.NoError(t, err)
+		mockBookRepo.AssertExpectations(t)
+	})
+
+	t.Run("Approve suggestion service: Already reviewed", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, contentfilter.NewWordlistFilter(nil))
+
+		suggestionID := uuid.New()
+
+		mockRepo.On("FindById", suggestionID.String()).Return(&Suggestion{ID: suggestionID, Status: StatusRejected}, nil)
+
+		result, err := service.Approve(suggestionID.String(), uuid.New().String(), &ReviewSuggestionRequest{})
+
+		require.ErrorIs(t, err, ErrAlreadyReviewed)
+		assert.Nil(t, result)
+
+		mockBooks.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Approve suggestion service: Claimed by another moderator first", func(t *testing.T) {
+		mockRepo := new(MockSuggestionRepository)
+		mockBooks := new(book.MockBookService)
+		service := NewSuggestionService(mockRepo, mockBooks, contentfilter.NewWordlistFilter(nil))
+
+		bookID := uuid.New()
+		suggestionID := uuid.New()
+
+		mockRepo.On("FindById", suggestionID.String()).Return(&Suggestion{ID: suggestionID, BookID: bookID, Changes: Changes{PageCount: intPtr(180)}, Status: StatusPending}, nil)
+		mockRepo.On("Transaction").Return(nil)
+		mockRepo.On("UpdateReview", mock.Anything).Return((*Suggestion)(nil), ErrAlreadyReviewed)
+
+		result, err := service.Approve(suggestionID.String(), uuid.New().String(), &ReviewSuggestionRequest{})
+
+		require.ErrorIs(t, err, ErrAlreadyReviewed)
+		assert.Nil(t, result)
+
+		mockRepo.AssertNotCalled(t, "Books")
+		mockBooks.AssertNotCalled(t, "Reindex", mock.Anything)
+	})
+}
+
+func TestChangesDiff(t *testing.T) {
+	publicationDate := time.Date(1925, 4, 10, 0, 0, 0, 0, time.UTC)
+
+	current := &book.Book{
+		Title:           "The Grate Gatsby",
+		Author:          "F. Scott Fitzgerald",
+		PublishedYear:   1925,
+		PublicationDate: &publicationDate,
+	}
+
+	changes := Changes{Title: stringPtr("The Great Gatsby"), PublicationDate: stringPtr("1925-04-11")}
+
+	diff, err := changes.Diff(current)
+
+	require.NoError(t, err)
+	require.Len(t, diff, 2)
+	assert.Equal(t, FieldDiff{Field: "publication_date", Current: "1925-04-10", Proposed: "1925-04-11"}, diff[0])
+	assert.Equal(t, FieldDiff{Field: "title", Current: "The Grate Gatsby", Proposed: "The Great Gatsby"}, diff[1])
+}
@@ -0,0 +1,215 @@
+package suggestion
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/book"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+var (
+	ErrNoChanges       = errors.New("the suggestion does not change any field")
+	ErrAlreadyReviewed = errors.New("the suggestion has already been reviewed")
+)
+
+// Changes holds the proposed values of a suggestion. Fields that are nil are
+// left untouched when the suggestion is applied.
+type Changes struct {
+	Title           *string `json:"title,omitempty" validate:"omitnil,min=1,max=255" example:"The Great Gatsby"`
+	Subtitle        *string `json:"subtitle,omitempty" validate:"omitnil,max=255" example:"A Novel"`
+	OriginalTitle   *string `json:"original_title,omitempty" validate:"omitnil,max=255" example:"The Great Gatsby"`
+	Author          *string `json:"author,omitempty" validate:"omitnil,min=1" example:"F. Scott Fitzgerald"`
+	Description     *string `json:"description,omitempty" example:"A portrait of the Jazz Age in all of its decadence and excess."`
+	PublishedYear   *int    `json:"published_year,omitempty" validate:"omitnil,gt=0,notfuture" example:"1925"`
+	PublicationDate *string `json:"publication_date,omitempty" validate:"omitnil,eq=|datetime=2006-01-02,eq=|notfuture" example:"1925-04-10"`
+	ISBN            *string `json:"isbn,omitempty" validate:"omitnil,min=1" example:"9780743273565"`
+	PageCount       *int    `json:"page_count,omitempty" validate:"omitnil,gte=0" example:"180"`
+	Language        *string `json:"language,omitempty" validate:"omitnil,eq=|bcp47_language_tag" example:"en-US"`
+	Format          *string `json:"format,omitempty" validate:"omitnil,eq=|oneof=hardcover paperback ebook audiobook" example:"paperback"`
+}
+
+func (c Changes) IsEmpty() bool {
+	return c == Changes{}
+}
+
+// Apply returns a copy of current with the changed fields replaced.
+func (c Changes) Apply(current *book.Book) *book.Book {
+	updated := *current
+
+	if c.Title != nil {
+		updated.Title = *c.Title
+	}
+	if c.Subtitle != nil {
+		updated.Subtitle = *c.Subtitle
+	}
+	if c.OriginalTitle != nil {
+		updated.OriginalTitle = *c.OriginalTitle
+	}
+	if c.Author != nil {
+		updated.Author = *c.Author
+	}
+	if c.Description != nil {
+		updated.Description = *c.Description
+	}
+	if c.PublishedYear != nil {
+		updated.PublishedYear = *c.PublishedYear
+	}
+	if c.PublicationDate != nil {
+		updated.PublicationDate = nil
+
+		date, err := time.Parse(book.DateLayout, *c.PublicationDate)
+		if err == nil {
+			updated.PublicationDate = &date
+		}
+	}
+	if c.ISBN != nil {
+		updated.ISBN = *c.ISBN
+	}
+	if c.PageCount != nil {
+		updated.PageCount = *c.PageCount
+	}
+	if c.Language != nil {
+		updated.Language = *c.Language
+	}
+	if c.Format != nil {
+		updated.Format = *c.Format
+	}
+
+	return &updated
+}
+
+// Diff lists every proposed field next to its current value, ordered by field
+// name.
+func (c Changes) Diff(current *book.Book) ([]FieldDiff, error) {
+	currentValues, err := toMap(currentRequest(current))
+	if err != nil {
+		return nil, err
+	}
+
+	proposedValues, err := toMap(c)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make([]FieldDiff, 0, len(proposedValues))
+
+	for field, proposed := range proposedValues {
+		diff = append(diff, FieldDiff{
+			Field:    field,
+			Current:  currentValues[field],
+			Proposed: proposed,
+		})
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Field < diff[j].Field
+	})
+
+	return diff, nil
+}
+
+func currentRequest(current *book.Book) *book.UpdateBookRequest {
+	req := &book.UpdateBookRequest{
+		Title:         current.Title,
+		Subtitle:      current.Subtitle,
+		OriginalTitle: current.OriginalTitle,
+		Author:        current.Author,
+		Description:   current.Description,
+		PublishedYear: current.PublishedYear,
+		ISBN:          current.ISBN,
+		PageCount:     current.PageCount,
+		Language:      current.Language,
+		Format:        current.Format,
+	}
+
+	if current.PublicationDate != nil {
+		req.PublicationDate = current.PublicationDate.Format(book.DateLayout)
+	}
+
+	return req
+}
+
+func toMap(v any) (map[string]any, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]any)
+
+	err = json.Unmarshal(js, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type Suggestion struct {
+	ID            uuid.UUID
+	BookID        uuid.UUID
+	Changes       Changes
+	Comment       string
+	Status        string
+	SubmittedBy   string
+	ReviewedBy    string
+	ReviewComment string
+	ReviewedAt    *time.Time
+	Flags         []string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type FieldDiff struct {
+	Field    string `json:"field" example:"title"`
+	Current  any    `json:"current"`
+	Proposed any    `json:"proposed"`
+}
+
+type SubmitSuggestionRequest struct {
+	Changes Changes `json:"changes" validate:"required"`
+	Comment string  `json:"comment,omitempty" validate:"max=1000" example:"The title is missing its subtitle."`
+}
+
+type ReviewSuggestionRequest struct {
+	Comment string `json:"comment,omitempty" validate:"max=1000" example:"Checked against the publisher's catalogue."`
+}
+
+type SuggestionResponse struct {
+	ID            string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	BookID        string     `json:"book_id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Changes       Changes    `json:"changes"`
+	Comment       string     `json:"comment,omitempty" example:"The title is missing its subtitle."`
+	Status        string     `json:"status" example:"pending"`
+	SubmittedBy   string     `json:"submitted_by" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ReviewedBy    string     `json:"reviewed_by,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ReviewComment string     `json:"review_comment,omitempty" example:"Checked against the publisher's catalogue."`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty" example:"2024-01-01T00:00:00Z"`
+	Flags         []string   `json:"flags,omitempty" example:"Comment: violates content policy: spam"`
+	CreatedAt     time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+func newSuggestionResponse(suggestion *Suggestion) SuggestionResponse {
+	return SuggestionResponse{
+		ID:            suggestion.ID.String(),
+		BookID:        suggestion.BookID.String(),
+		Changes:       suggestion.Changes,
+		Comment:       suggestion.Comment,
+		Status:        suggestion.Status,
+		SubmittedBy:   suggestion.SubmittedBy,
+		ReviewedBy:    suggestion.ReviewedBy,
+		ReviewComment: suggestion.ReviewComment,
+		ReviewedAt:    suggestion.ReviewedAt,
+		Flags:         suggestion.Flags,
+		CreatedAt:     suggestion.CreatedAt,
+	}
+}
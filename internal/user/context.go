@@ -0,0 +1,22 @@
+package user
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey string
+
+const userContextKey = contextKey("user")
+
+// ContextSetUser returns a copy of r carrying the authenticated user.
+func ContextSetUser(r *http.Request, u *User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, u)
+	return r.WithContext(ctx)
+}
+
+// ContextGetUser returns the authenticated user of r, if any.
+func ContextGetUser(r *http.Request) (*User, bool) {
+	u, ok := r.Context().Value(userContextKey).(*User)
+	return u, ok
+}
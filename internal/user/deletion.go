@@ -0,0 +1,46 @@
+package user
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+)
+
+// ScheduleDeletion marks the account of a user to be deleted at the given
+// time. Until then the user can still log in and cancel the deletion.
+func (s *userService) ScheduleDeletion(id string, at time.Time) (*User, error) {
+	err := s.repo.ScheduleDeletion(id, at)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return nil, ErrDeletionScheduled
+		default:
+			return nil, err
+		}
+	}
+
+	return s.repo.FindById(id)
+}
+
+func (s *userService) CancelDeletion(id string) error {
+	err := s.repo.CancelDeletion(id)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return ErrNoDeletionScheduled
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListDueForDeletion returns the IDs of the users whose grace period has
+// passed.
+func (s *userService) ListDueForDeletion(now time.Time) ([]string, error) {
+	return s.repo.FindDueForDeletion(now)
+}
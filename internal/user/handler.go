@@ -0,0 +1,246 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/contentfilter"
+)
+
+type UserHandler struct {
+	service UserService
+}
+
+func NewUserHandler(service UserService) *UserHandler {
+	return &UserHandler{
+		service: service,
+	}
+}
+
+// Register godoc
+// @Summary Register a new user
+// @Description Create a user account. Passwords need at least 8 characters from at least three of lowercase letters, uppercase letters, digits and other characters.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body RegisterRequest true "Account details"
+// @Success 201 {object} UserResponse
+// @Router /users [post]
+func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req RegisterRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	user, err := h.service.Register(&req)
+
+	if err != nil {
+		switch err {
+		case ErrDuplicateEmail:
+			common.ConflictResponse(w, r, err)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusCreated, common.Envelope{"user": newUserResponse(user)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// GetProfile godoc
+// @Summary Get the public profile of a user
+// @Description Get the display name, bio, avatar and the number of books reviewed and read by a user. Stats are left out if the user hides their activity. Users whose account is about to be deleted have no profile.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {object} PublicProfileResponse
+// @Router /users/{id} [get]
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	profile, err := h.service.GetProfile(id)
+
+	if err != nil {
+		switch err {
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if profile.DeletionScheduled {
+		common.NotFoundResponse(w, r)
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"profile": newPublicProfileResponse(profile)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// GetOwnProfile godoc
+// @Summary Get your profile
+// @Description Get the profile of the current user with its settings. Stats are included even if they are hidden from others.
+// @Tags profile
+// @Produce json
+// @Success 200 {object} ProfileResponse
+// @Security BearerAuth
+// @Router /me/profile [get]
+func (h *UserHandler) GetOwnProfile(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	profile, err := h.service.GetProfile(u.ID.String())
+
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"profile": newProfileResponse(profile)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// UpdateProfile godoc
+// @Summary Update your profile
+// @Description Replace the display name, bio, avatar and privacy settings of the current user. Avatars are linked by HTTPS URL. The display name and bio are checked by the content filter.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param profile body UpdateProfileRequest true "Profile"
+// @Success 200 {object} ProfileResponse
+// @Security BearerAuth
+// @Router /me/profile [put]
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	u, _ := ContextGetUser(r)
+
+	var req UpdateProfileRequest
+
+	err := common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(u.ID.String(), &req)
+
+	if err != nil {
+		var rejectedErr *contentfilter.RejectedError
+
+		switch {
+		case errors.As(err, &rejectedErr):
+			common.FailedValidationResponse(w, r, rejectedErr.Fields)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"profile": newProfileResponse(profile)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// SetRole godoc
+// @Summary Change the role of a user
+// @Description Change the role of a user. Only admins may change roles.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param role body SetRoleRequest true "New role"
+// @Success 200 {object} UserResponse
+// @Security BearerAuth
+// @Router /users/{id}/role [put]
+func (h *UserHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	id, err := common.GetIdFromRequest(r, "id")
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var req SetRoleRequest
+
+	err = common.ReadJSON(w, r, &req)
+
+	if err != nil {
+		common.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if !validateRequest(w, r, req) {
+		return
+	}
+
+	user, err := h.service.SetRole(id, req.Role)
+
+	if err != nil {
+		switch err {
+		case common.ErrNotFound:
+			common.NotFoundResponse(w, r)
+		default:
+			common.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = common.WriteJSON(w, http.StatusOK, common.Envelope{"user": newUserResponse(user)}, nil)
+	if err != nil {
+		common.ServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// validateRequest validates req with the user validation rules and writes a
+// failed validation response if it is invalid.
+func validateRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	validate := NewValidator()
+
+	err := validate.Struct(req)
+
+	if err != nil {
+		errors := make(map[string]string)
+
+		for _, err := range err.(validator.ValidationErrors) {
+			errors[err.Field()] = err.Tag()
+		}
+
+		common.FailedValidationResponse(w, r, errors)
+		return false
+	}
+
+	return true
+}
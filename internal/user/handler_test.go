@@ -0,0 +1,281 @@
+//go:build unit
+// +build unit
+
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/contentfilter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterHandler(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	t.Run("POST Register handler: Successfully register a user", func(t *testing.T) {
+		req := RegisterRequest{Email: "jane.doe@example.com", Name: "Jane Doe", Password: "correct-Horse-battery-5taple"}
+
+		created := &User{ID: uuid.New(), Email: req.Email, Name: req.Name, PasswordHash: []byte("hash"), CreatedAt: time.Now()}
+
+		mockService.On("Register", &req).Return(created, nil).Once()
+
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/users", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.Register(w, r)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotContains(t, w.Body.String(), "hash")
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		user, ok := response["user"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, created.ID.String(), user["id"])
+		assert.Equal(t, "jane.doe@example.com", user["email"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("POST Register handler: Weak password", func(t *testing.T) {
+		body, _ := json.Marshal(RegisterRequest{Email: "jane.doe@example.com", Name: "Jane Doe", Password: "password"})
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/users", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.Register(w, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response map[string]map[string]string
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, "password", response["error"]["Password"])
+	})
+
+	t.Run("POST Register handler: Email already registered", func(t *testing.T) {
+		mockService.On("Register", mock.AnythingOfType("*user.RegisterRequest")).Return((*User)(nil), ErrDuplicateEmail).Once()
+
+		body, _ := json.Marshal(RegisterRequest{Email: "jane.doe@example.com", Name: "Jane Doe", Password: "correct-Horse-battery-5taple"})
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/users", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.Register(w, r)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestSetRoleHandler(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	t.Run("PUT Role handler: Successfully change the role", func(t *testing.T) {
+		id := uuid.New()
+
+		mockService.On("SetRole", id.String(), RoleEditor).Return(&User{ID: id, Email: "jane.doe@example.com", Role: RoleEditor}, nil).Once()
+
+		r := httptest.NewRequest(http.MethodPut, "/v1/api/users/"+id.String()+"/role", bytes.NewReader([]byte(`{"role": "editor"}`)))
+		w := httptest.NewRecorder()
+
+		router := chi.NewRouter()
+		router.Put("/v1/api/users/{id}/role", handler.SetRole)
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, "editor", response["user"]["role"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PUT Role handler: Unknown role", func(t *testing.T) {
+		id := uuid.New()
+
+		r := httptest.NewRequest(http.MethodPut, "/v1/api/users/"+id.String()+"/role", bytes.NewReader([]byte(`{"role": "owner"}`)))
+		w := httptest.NewRecorder()
+
+		router := chi.NewRouter()
+		router.Put("/v1/api/users/{id}/role", handler.SetRole)
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNumberOfCalls(t, "SetRole", 1)
+	})
+}
+
+func TestGetProfileHandler(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	router := chi.NewRouter()
+	router.Get("/v1/api/users/{id}", handler.GetProfile)
+
+	profile := &Profile{
+		UserID:    uuid.New(),
+		Name:      "Jane Doe",
+		Bio:       "Reads mostly classics.",
+		Stats:     ProfileStats{ReviewCount: 3, BooksRead: 12},
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("GET Profile handler: Public profile with stats", func(t *testing.T) {
+		mockService.On("GetProfile", profile.UserID.String()).Return(profile, nil).Once()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/api/users/"+profile.UserID.String(), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]PublicProfileResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		// The name the user registered with is not public
+		assert.Empty(t, response["profile"].DisplayName)
+		assert.NotContains(t, w.Body.String(), "Jane Doe")
+		require.NotNil(t, response["profile"].Stats)
+		assert.Equal(t, 3, response["profile"].Stats.ReviewCount)
+		assert.Equal(t, 12, response["profile"].Stats.BooksRead)
+		assert.NotContains(t, w.Body.String(), "hide_activity")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GET Profile handler: Hidden activity", func(t *testing.T) {
+		hidden := *profile
+		hidden.DisplayName = "Jane"
+		hidden.HideActivity = true
+
+		mockService.On("GetProfile", profile.UserID.String()).Return(&hidden, nil).Once()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/api/users/"+profile.UserID.String(), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Jane", response["profile"]["display_name"])
+		assert.NotContains(t, response["profile"], "stats")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GET Profile handler: Unknown user", func(t *testing.T) {
+		id := uuid.NewString()
+
+		mockService.On("GetProfile", id).Return((*Profile)(nil), common.ErrNotFound).Once()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/api/users/"+id, nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestOwnProfileHandlers(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	u := &User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+	t.Run("GET Own profile handler: Hidden stats are included", func(t *testing.T) {
+		profile := &Profile{UserID: u.ID, DisplayName: "Jane", HideActivity: true, Stats: ProfileStats{ReviewCount: 3, BooksRead: 12}}
+
+		mockService.On("GetProfile", u.ID.String()).Return(profile, nil).Once()
+
+		r := ContextSetUser(httptest.NewRequest(http.MethodGet, "/v1/api/me/profile", nil), u)
+		w := httptest.NewRecorder()
+
+		handler.GetOwnProfile(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]ProfileResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		// Owners see their own stats even when they hide them
+		assert.True(t, response["profile"].HideActivity)
+		assert.Equal(t, 3, response["profile"].Stats.ReviewCount)
+		assert.Equal(t, 12, response["profile"].Stats.BooksRead)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PUT Update profile handler: Successfully update the profile", func(t *testing.T) {
+		req := UpdateProfileRequest{DisplayName: "Jane", AvatarURL: "https://example.com/avatars/jane.png", HideActivity: true}
+		updated := &Profile{UserID: u.ID, DisplayName: "Jane", AvatarURL: req.AvatarURL, HideActivity: true}
+
+		mockService.On("UpdateProfile", u.ID.String(), &req).Return(updated, nil).Once()
+
+		body, _ := json.Marshal(req)
+		r := ContextSetUser(httptest.NewRequest(http.MethodPut, "/v1/api/me/profile", bytes.NewReader(body)), u)
+		w := httptest.NewRecorder()
+
+		handler.UpdateProfile(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]ProfileResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.True(t, response["profile"].HideActivity)
+		assert.Equal(t, req.AvatarURL, response["profile"].AvatarURL)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PUT Update profile handler: Avatar not served over HTTPS", func(t *testing.T) {
+		body, _ := json.Marshal(UpdateProfileRequest{AvatarURL: "http://example.com/avatars/jane.png"})
+		r := ContextSetUser(httptest.NewRequest(http.MethodPut, "/v1/api/me/profile", bytes.NewReader(body)), u)
+		w := httptest.NewRecorder()
+
+		handler.UpdateProfile(w, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNumberOfCalls(t, "UpdateProfile", 1)
+	})
+
+	t.Run("PUT Update profile handler: Bio rejected by the content filter", func(t *testing.T) {
+		req := UpdateProfileRequest{Bio: "Buy cheap pills"}
+
+		mockService.On("UpdateProfile", u.ID.String(), &req).Return((*Profile)(nil), &contentfilter.RejectedError{Fields: map[string]string{"Bio": "violates content policy: spam"}}).Once()
+
+		body, _ := json.Marshal(req)
+		r := ContextSetUser(httptest.NewRequest(http.MethodPut, "/v1/api/me/profile", bytes.NewReader(body)), u)
+		w := httptest.NewRecorder()
+
+		handler.UpdateProfile(w, r)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "violates content policy")
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,269 @@
+package user
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+type MockUserService struct {
+	mock.Mock
+}
+
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Save(user *User) (*User, error) {
+	args := m.Called(user)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindById(id string) (*User, error) {
+	args := m.Called(id)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmail(email string) (*User, error) {
+	args := m.Called(email)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) Register(req *RegisterRequest) (*User, error) {
+	args := m.Called(req)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) Login(req *LoginRequest) (*User, error) {
+	args := m.Called(req)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) GetUserById(id string) (*User, error) {
+	args := m.Called(id)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateRole(id, role string) (*User, error) {
+	args := m.Called(id, role)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) SetRole(id, role string) (*User, error) {
+	args := m.Called(id, role)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByIdentity(issuer, subject string) (*User, error) {
+	args := m.Called(issuer, subject)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserRepository) SaveIdentity(identity *Identity) (*Identity, error) {
+	args := m.Called(identity)
+	return args.Get(0).(*Identity), args.Error(1)
+}
+
+func (m *MockUserRepository) SaveWithIdentity(user *User, identity *Identity) (*User, error) {
+	args := m.Called(user, identity)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) LoginExternal(external *ExternalUser) (*User, error) {
+	args := m.Called(external)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) LinkExternal(id string, external *ExternalUser) (*User, error) {
+	args := m.Called(id, external)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(id string, hash []byte) error {
+	args := m.Called(id, hash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetTOTPSecret(id string, secret []byte) error {
+	args := m.Called(id, secret)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) EnableTwoFactor(id string, step int64, recoveryCodeHashes [][]byte) error {
+	args := m.Called(id, step, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DisableTwoFactor(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseTOTPStep(id string, step int64) error {
+	args := m.Called(id, step)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ClaimTwoFactorAttempt(id string, max int, lockout time.Duration) error {
+	args := m.Called(id, max, lockout)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ResetTwoFactorAttempts(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseRecoveryCode(id string, hash []byte) error {
+	args := m.Called(id, hash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ReplaceRecoveryCodes(id string, hashes [][]byte) error {
+	args := m.Called(id, hashes)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) Save(token *Token) (*Token, error) {
+	args := m.Called(token)
+	return args.Get(0).(*Token), args.Error(1)
+}
+
+func (m *MockTokenRepository) Consume(hash []byte, purpose string) (*Token, error) {
+	args := m.Called(hash, purpose)
+	return args.Get(0).(*Token), args.Error(1)
+}
+
+func (m *MockTokenRepository) CountSince(userID, purpose string, since time.Time) (int, error) {
+	args := m.Called(userID, purpose, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) InvalidateAll(userID, purpose string) error {
+	args := m.Called(userID, purpose)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) FindByUser(userID string) ([]Token, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]Token), args.Error(1)
+}
+
+func (m *MockUserService) SendVerificationEmail(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserService) VerifyEmail(token string) (*User, error) {
+	args := m.Called(token)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) RequestPasswordReset(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResetPassword(req *ResetPasswordRequest) (*User, error) {
+	args := m.Called(req)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) BeginTwoFactor(id string) (*TwoFactorEnrollment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*TwoFactorEnrollment), args.Error(1)
+}
+
+func (m *MockUserService) ConfirmTwoFactor(id, code string) ([]string, error) {
+	args := m.Called(id, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserService) VerifyTwoFactor(id, code string) error {
+	args := m.Called(id, code)
+	return args.Error(0)
+}
+
+func (m *MockUserService) DisableTwoFactor(id, code string) error {
+	args := m.Called(id, code)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RegenerateRecoveryCodes(id, code string) ([]string, error) {
+	args := m.Called(id, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) FindProfile(id string) (*Profile, error) {
+	args := m.Called(id)
+	return args.Get(0).(*Profile), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateProfile(profile *Profile) error {
+	args := m.Called(profile)
+	return args.Error(0)
+}
+
+func (m *MockUserService) GetProfile(id string) (*Profile, error) {
+	args := m.Called(id)
+	return args.Get(0).(*Profile), args.Error(1)
+}
+
+func (m *MockUserService) UpdateProfile(id string, req *UpdateProfileRequest) (*Profile, error) {
+	args := m.Called(id, req)
+	return args.Get(0).(*Profile), args.Error(1)
+}
+
+func (m *MockUserRepository) ScheduleDeletion(id string, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CancelDeletion(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindDueForDeletion(now time.Time) ([]string, error) {
+	args := m.Called(now)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) FindIdentities(userID string) ([]Identity, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]Identity), args.Error(1)
+}
+
+func (m *MockUserService) ScheduleDeletion(id string, at time.Time) (*User, error) {
+	args := m.Called(id, at)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockUserService) CancelDeletion(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ListDueForDeletion(now time.Time) ([]string, error) {
+	args := m.Called(now)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserService) ListIdentities(id string) ([]Identity, error) {
+	args := m.Called(id)
+	return args.Get(0).([]Identity), args.Error(1)
+}
+
+func (m *MockUserService) ListTokens(id string) ([]Token, error) {
+	args := m.Called(id)
+	return args.Get(0).([]Token), args.Error(1)
+}
@@ -0,0 +1,671 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/database"
+)
+
+type UserRepository interface {
+	Save(user *User) (*User, error)
+	FindById(id string) (*User, error)
+	FindByEmail(email string) (*User, error)
+	UpdateRole(id, role string) (*User, error)
+	FindByIdentity(issuer, subject string) (*User, error)
+	SaveIdentity(identity *Identity) (*Identity, error)
+	SaveWithIdentity(user *User, identity *Identity) (*User, error)
+	FindIdentities(userID string) ([]Identity, error)
+	MarkEmailVerified(id string) error
+	UpdatePassword(id string, hash []byte) error
+	SetTOTPSecret(id string, secret []byte) error
+	EnableTwoFactor(id string, step int64, recoveryCodeHashes [][]byte) error
+	DisableTwoFactor(id string) error
+	UseTOTPStep(id string, step int64) error
+	UseRecoveryCode(id string, hash []byte) error
+	ClaimTwoFactorAttempt(id string, max int, lockout time.Duration) error
+	ResetTwoFactorAttempts(id string) error
+	ReplaceRecoveryCodes(id string, hashes [][]byte) error
+	FindProfile(id string) (*Profile, error)
+	UpdateProfile(profile *Profile) error
+	ScheduleDeletion(id string, at time.Time) error
+	CancelDeletion(id string) error
+	FindDueForDeletion(now time.Time) ([]string, error)
+}
+
+type TokenRepository interface {
+	Save(token *Token) (*Token, error)
+	Consume(hash []byte, purpose string) (*Token, error)
+	CountSince(userID, purpose string, since time.Time) (int, error)
+	InvalidateAll(userID, purpose string) error
+	FindByUser(userID string) ([]Token, error)
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{
+		db: db,
+	}
+}
+
+func (r *userRepository) Save(user *User) (*User, error) {
+	query := `
+		INSERT INTO users (id, email, name, role, password_hash, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, user.ID, user.Email, user.Name, user.Role, user.PasswordHash, user.EmailVerifiedAt).Scan(&user.CreatedAt, &user.UpdatedAt)
+
+	if err != nil {
+		switch {
+		case database.IsUniqueViolation(err):
+			return nil, ErrDuplicateEmail
+		default:
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) FindById(id string) (*User, error) {
+	query := `
+		SELECT id, email, name, role, password_hash, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, created_at, updated_at
+		FROM users
+		WHERE id = $1`
+
+	return r.findOne(query, id)
+}
+
+// FindByEmail looks up a user by email address, ignoring case.
+func (r *userRepository) FindByEmail(email string) (*User, error) {
+	query := `
+		SELECT id, email, name, role, password_hash, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, created_at, updated_at
+		FROM users
+		WHERE LOWER(email) = LOWER($1)`
+
+	return r.findOne(query, email)
+}
+
+func (r *userRepository) UpdateRole(id, role string) (*User, error) {
+	query := `
+		UPDATE users
+		SET role = $1
+		WHERE id = $2
+		RETURNING id, email, name, role, password_hash, email_verified_at, totp_secret, totp_enabled_at, deletion_scheduled_at, created_at, updated_at`
+
+	return r.findOne(query, role, id)
+}
+
+// FindByIdentity looks up the user linked to the account with the given
+// subject at an identity provider.
+func (r *userRepository) FindByIdentity(issuer, subject string) (*User, error) {
+	query := `
+		SELECT u.id, u.email, u.name, u.role, u.password_hash, u.email_verified_at, u.totp_secret, u.totp_enabled_at, u.deletion_scheduled_at, u.created_at, u.updated_at
+		FROM users u
+		JOIN user_identities i ON i.user_id = u.id
+		WHERE i.issuer = $1 AND i.subject = $2`
+
+	return r.findOne(query, issuer, subject)
+}
+
+func (r *userRepository) SaveIdentity(identity *Identity) (*Identity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := saveIdentity(ctx, r.db, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// FindIdentities returns the accounts at identity providers linked to a user,
+// oldest first.
+func (r *userRepository) FindIdentities(userID string) ([]Identity, error) {
+	query := `
+		SELECT id, user_id, issuer, subject, email, created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []Identity{}
+
+	for rows.Next() {
+		var identity Identity
+
+		err := rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Issuer,
+			&identity.Subject,
+			&identity.Email,
+			&identity.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+// SaveWithIdentity saves a user together with its first identity, so users
+// provisioned by an identity provider are never left without one.
+func (r *userRepository) SaveWithIdentity(user *User, identity *Identity) (*User, error) {
+	query := `
+		INSERT INTO users (id, email, name, role, password_hash, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at`
+
+	err := r.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, user.ID, user.Email, user.Name, user.Role, user.PasswordHash, user.EmailVerifiedAt).Scan(&user.CreatedAt, &user.UpdatedAt)
+
+		if err != nil {
+			switch {
+			case database.IsUniqueViolation(err):
+				return ErrDuplicateEmail
+			default:
+				return err
+			}
+		}
+
+		identity.UserID = user.ID
+
+		return saveIdentity(ctx, tx, identity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) MarkEmailVerified(id string) error {
+	query := `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id = $1`
+
+	return r.exec(query, id)
+}
+
+// UpdatePassword replaces the password hash of a user. Only the owner of the
+// email address can reset a password, so the address is marked as verified
+// as well.
+func (r *userRepository) UpdatePassword(id string, hash []byte) error {
+	query := `
+		UPDATE users
+		SET password_hash = $1, email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id = $2`
+
+	return r.exec(query, hash, id)
+}
+
+// FindProfile returns the profile of a user with the number of books they
+// reviewed and read.
+func (r *userRepository) FindProfile(id string) (*Profile, error) {
+	query := `
+		SELECT u.id, u.name, u.display_name, u.bio, u.avatar_url, u.hide_activity, u.deletion_scheduled_at IS NOT NULL, u.created_at,
+			(SELECT COUNT(*) FROM reviews WHERE user_id = u.id),
+			(SELECT COUNT(*) FROM shelf_entries WHERE user_id = u.id AND shelf = 'read')
+		FROM users u
+		WHERE u.id = $1`
+
+	var profile Profile
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&profile.UserID,
+		&profile.Name,
+		&profile.DisplayName,
+		&profile.Bio,
+		&profile.AvatarURL,
+		&profile.HideActivity,
+		&profile.DeletionScheduled,
+		&profile.CreatedAt,
+		&profile.Stats.ReviewCount,
+		&profile.Stats.BooksRead,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, common.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &profile, nil
+}
+
+func (r *userRepository) UpdateProfile(profile *Profile) error {
+	query := `
+		UPDATE users
+		SET display_name = $1, bio = $2, avatar_url = $3, hide_activity = $4
+		WHERE id = $5`
+
+	return r.exec(query, profile.DisplayName, profile.Bio, profile.AvatarURL, profile.HideActivity, profile.UserID)
+}
+
+// ScheduleDeletion marks a user to be deleted at the given time. Users who
+// already scheduled their deletion are reported as not found.
+func (r *userRepository) ScheduleDeletion(id string, at time.Time) error {
+	query := `
+		UPDATE users
+		SET deletion_scheduled_at = $1
+		WHERE id = $2 AND deletion_scheduled_at IS NULL`
+
+	return r.exec(query, at, id)
+}
+
+// CancelDeletion keeps a user who scheduled their deletion. Users without a
+// scheduled deletion are reported as not found.
+func (r *userRepository) CancelDeletion(id string) error {
+	query := `
+		UPDATE users
+		SET deletion_scheduled_at = NULL
+		WHERE id = $1 AND deletion_scheduled_at IS NOT NULL`
+
+	return r.exec(query, id)
+}
+
+// FindDueForDeletion returns the IDs of the users whose deletion is due.
+func (r *userRepository) FindDueForDeletion(now time.Time) ([]string, error) {
+	query := `
+		SELECT id
+		FROM users
+		WHERE deletion_scheduled_at <= $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+
+	for rows.Next() {
+		var id string
+
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// SetTOTPSecret starts enrollment in two-factor authentication with a new
+// secret. Users who already enabled it are reported as not found.
+func (r *userRepository) SetTOTPSecret(id string, secret []byte) error {
+	query := `
+		UPDATE users
+		SET totp_secret = $1, totp_last_step = NULL
+		WHERE id = $2 AND totp_enabled_at IS NULL`
+
+	return r.exec(query, secret, id)
+}
+
+// EnableTwoFactor completes enrollment with the step of the confirmed code
+// and replaces the recovery codes of the user.
+func (r *userRepository) EnableTwoFactor(id string, step int64, recoveryCodeHashes [][]byte) error {
+	query := `
+		UPDATE users
+		SET totp_enabled_at = CURRENT_TIMESTAMP, totp_last_step = $1
+		WHERE id = $2 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL`
+
+	return r.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, step, id)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return common.ErrNotFound
+		}
+
+		return replaceRecoveryCodes(ctx, tx, id, recoveryCodeHashes)
+	})
+}
+
+// DisableTwoFactor removes the TOTP secret and recovery codes of a user.
+func (r *userRepository) DisableTwoFactor(id string) error {
+	query := `
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL
+		WHERE id = $1`
+
+	return r.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, id)
+		if err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, tx, id, nil)
+	})
+}
+
+// UseTOTPStep records that the code of a time step was used. A step that is
+// not newer than the last one used is reported as not found, so every code
+// works only once.
+func (r *userRepository) UseTOTPStep(id string, step int64) error {
+	query := `
+		UPDATE users
+		SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`
+
+	return r.exec(query, step, id)
+}
+
+// UseRecoveryCode marks a recovery code of a user as used. Unknown and used
+// codes are reported as not found.
+func (r *userRepository) UseRecoveryCode(id string, hash []byte) error {
+	query := `
+		UPDATE recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	return r.exec(query, id, hash)
+}
+
+// ClaimTwoFactorAttempt counts an attempt at entering a second factor before
+// the code is checked, so concurrent guesses are counted too. The attempt
+// that reaches max locks the user out for lockout; while locked out, no
+// attempts are granted and ErrNotFound is returned. The count starts over
+// once a lockout has passed. The count is computed from the row being
+// updated, which concurrent updates of the user wait for and then re-read.
+func (r *userRepository) ClaimTwoFactorAttempt(id string, max int, lockout time.Duration) error {
+	query := `
+		UPDATE users
+		SET totp_attempts = CASE WHEN totp_locked_until IS NULL THEN totp_attempts ELSE 0 END + 1,
+			totp_locked_until = CASE
+				WHEN CASE WHEN totp_locked_until IS NULL THEN totp_attempts ELSE 0 END + 1 >= $2
+				THEN CURRENT_TIMESTAMP + make_interval(secs => $3)
+			END
+		WHERE id = $1 AND (totp_locked_until IS NULL OR totp_locked_until <= CURRENT_TIMESTAMP)`
+
+	return r.exec(query, id, max, lockout.Seconds())
+}
+
+// ResetTwoFactorAttempts starts the count of attempts over after a second
+// factor was accepted.
+func (r *userRepository) ResetTwoFactorAttempts(id string) error {
+	query := `
+		UPDATE users
+		SET totp_attempts = 0, totp_locked_until = NULL
+		WHERE id = $1`
+
+	return r.exec(query, id)
+}
+
+func (r *userRepository) ReplaceRecoveryCodes(id string, hashes [][]byte) error {
+	return r.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, id, hashes)
+	})
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string, hashes [][]byte) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		_, err = tx.ExecContext(ctx, `INSERT INTO recovery_codes (id, user_id, code_hash) VALUES ($1, $2, $3)`, uuid.New(), userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *userRepository) inTx(fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return database.Transaction(ctx, r.db, func(tx *sql.Tx) error {
+		return fn(ctx, tx)
+	})
+}
+
+// exec runs a statement that updates a single user.
+func (r *userRepository) exec(query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return common.ErrNotFound
+	}
+
+	return nil
+}
+
+func saveIdentity(ctx context.Context, db database.DBTX, identity *Identity) error {
+	query := `
+		INSERT INTO user_identities (id, user_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	return db.QueryRowContext(ctx, query, identity.ID, identity.UserID, identity.Issuer, identity.Subject, identity.Email).Scan(&identity.CreatedAt)
+}
+
+func (r *userRepository) findOne(query string, args ...any) (*User, error) {
+	var user User
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Role,
+		&user.PasswordHash,
+		&user.EmailVerifiedAt,
+		&user.TOTPSecret,
+		&user.TwoFactorEnabledAt,
+		&user.DeletionScheduledAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, common.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+type tokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) TokenRepository {
+	return &tokenRepository{
+		db: db,
+	}
+}
+
+func (r *tokenRepository) Save(token *Token) (*Token, error) {
+	query := `
+		INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, token.ID, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt).Scan(&token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Consume marks an unused, unexpired token as used and returns it. Marking
+// and checking happen in one statement, so a token cannot be used twice by
+// concurrent requests.
+func (r *tokenRepository) Consume(hash []byte, purpose string) (*Token, error) {
+	query := `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+
+	var token Token
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, hash, purpose).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, common.ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &token, nil
+}
+
+// CountSince counts the tokens created for a user since the given time,
+// used or not.
+func (r *tokenRepository) CountSince(userID, purpose string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM user_tokens
+		WHERE user_id = $1 AND purpose = $2 AND created_at > $3`
+
+	var count int
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, userID, purpose, since).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// InvalidateAll marks every unused token of a user for purpose as used.
+func (r *tokenRepository) InvalidateAll(userID, purpose string) error {
+	query := `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, userID, purpose)
+	return err
+}
+
+// FindByUser returns the email verification and password reset tokens of a
+// user, oldest first.
+func (r *tokenRepository) FindByUser(userID string) ([]Token, error) {
+	query := `
+		SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at
+		FROM user_tokens
+		WHERE user_id = $1
+		ORDER BY created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []Token{}
+
+	for rows.Next() {
+		var token Token
+
+		err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Purpose,
+			&token.TokenHash,
+			&token.ExpiresAt,
+			&token.UsedAt,
+			&token.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
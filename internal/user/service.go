@@ -0,0 +1,313 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/contentfilter"
+	"github.com/jakottelaar/gobookreviewapp/pkg/mailer"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UserService interface {
+	Register(req *RegisterRequest) (*User, error)
+	Login(req *LoginRequest) (*User, error)
+	GetUserById(id string) (*User, error)
+	SetRole(id, role string) (*User, error)
+	LoginExternal(external *ExternalUser) (*User, error)
+	LinkExternal(id string, external *ExternalUser) (*User, error)
+	SendVerificationEmail(id string) error
+	VerifyEmail(token string) (*User, error)
+	RequestPasswordReset(email string) error
+	ResetPassword(req *ResetPasswordRequest) (*User, error)
+	BeginTwoFactor(id string) (*TwoFactorEnrollment, error)
+	ConfirmTwoFactor(id, code string) ([]string, error)
+	VerifyTwoFactor(id, code string) error
+	DisableTwoFactor(id, code string) error
+	RegenerateRecoveryCodes(id, code string) ([]string, error)
+	GetProfile(id string) (*Profile, error)
+	ListIdentities(id string) ([]Identity, error)
+	ListTokens(id string) ([]Token, error)
+	UpdateProfile(id string, req *UpdateProfileRequest) (*Profile, error)
+	ScheduleDeletion(id string, at time.Time) (*User, error)
+	CancelDeletion(id string) error
+	ListDueForDeletion(now time.Time) ([]string, error)
+}
+
+type userService struct {
+	repo   UserRepository
+	tokens TokenRepository
+	mailer mailer.Mailer
+	// appURL is the base URL of the links in emails.
+	appURL string
+	cost   int
+	// dummyHash is compared against when a user does not exist, so failed
+	// logins take as long whether or not the address is registered.
+	dummyHash []byte
+	// filter checks the text users show on their public profile.
+	filter contentfilter.ContentFilter
+}
+
+func NewUserService(repo UserRepository, tokens TokenRepository, mailer mailer.Mailer, appURL string, filter contentfilter.ContentFilter) UserService {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+	return &userService{
+		repo:      repo,
+		tokens:    tokens,
+		mailer:    mailer,
+		appURL:    strings.TrimSuffix(appURL, "/"),
+		cost:      bcrypt.DefaultCost,
+		dummyHash: dummyHash,
+		filter:    filter,
+	}
+}
+
+func (s *userService) Register(req *RegisterRequest) (*User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.cost)
+	if err != nil {
+		return nil, err
+	}
+
+	newUser := &User{
+		ID:           uuid.New(),
+		Email:        normalizeEmail(req.Email),
+		Name:         strings.TrimSpace(req.Name),
+		Role:         RoleReader,
+		PasswordHash: hash,
+	}
+
+	user, err := s.repo.Save(newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.sendToken(user, TokenPurposeVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Login returns the user with the given email address if the password
+// matches. Unknown addresses and wrong passwords both yield
+// ErrInvalidCredentials, so logins cannot be used to find out which addresses
+// are registered.
+func (s *userService) Login(req *LoginRequest) (*User, error) {
+	user, err := s.repo.FindByEmail(normalizeEmail(req.Email))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+			return nil, ErrInvalidCredentials
+		default:
+			return nil, err
+		}
+	}
+
+	// Users provisioned by an identity provider have no password to log in
+	// with.
+	if len(user.PasswordHash) == 0 {
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+		return nil, ErrInvalidCredentials
+	}
+
+	err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(req.Password))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return nil, ErrInvalidCredentials
+		default:
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (s *userService) GetUserById(id string) (*User, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *userService) SetRole(id, role string) (*User, error) {
+	return s.repo.UpdateRole(id, role)
+}
+
+func (s *userService) GetProfile(id string) (*Profile, error) {
+	return s.repo.FindProfile(id)
+}
+
+func (s *userService) ListIdentities(id string) ([]Identity, error) {
+	return s.repo.FindIdentities(id)
+}
+
+// ListTokens returns the email verification and password reset tokens of a
+// user, including the ones that were used or have expired.
+func (s *userService) ListTokens(id string) ([]Token, error) {
+	return s.tokens.FindByUser(id)
+}
+
+func (s *userService) UpdateProfile(id string, req *UpdateProfileRequest) (*Profile, error) {
+	profile := &Profile{
+		UserID:       uuid.MustParse(id),
+		DisplayName:  strings.TrimSpace(req.DisplayName),
+		Bio:          strings.TrimSpace(req.Bio),
+		AvatarURL:    req.AvatarURL,
+		HideActivity: req.HideActivity,
+	}
+
+	err := s.filterProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.UpdateProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindProfile(id)
+}
+
+// filterProfile runs the public text of a profile through the content filter.
+// Masked text replaces the submitted text and any rejected field fails the
+// update. Profiles are not reviewed by moderators, so flagged text is kept.
+func (s *userService) filterProfile(profile *Profile) error {
+	fields := []struct {
+		name string
+		text *string
+	}{
+		{"DisplayName", &profile.DisplayName},
+		{"Bio", &profile.Bio},
+	}
+
+	rejected := make(map[string]string)
+
+	for _, field := range fields {
+		result := s.filter.Check(*field.text)
+
+		switch result.Action {
+		case contentfilter.ActionReject:
+			rejected[field.name] = result.Reason()
+		case contentfilter.ActionMask:
+			*field.text = result.Text
+		}
+	}
+
+	if len(rejected) > 0 {
+		return &contentfilter.RejectedError{Fields: rejected}
+	}
+
+	return nil
+}
+
+// LoginExternal returns the user linked to an account at an identity
+// provider. On the first login the account is linked to the user with the
+// same email address if both the provider and the user have verified that
+// address, or a new user without a password is created. Otherwise a
+// registered address yields ErrDuplicateEmail, as whoever registered it may
+// not own it; the user can link the account with LinkExternal after logging
+// in instead.
+func (s *userService) LoginExternal(external *ExternalUser) (*User, error) {
+	user, err := s.repo.FindByIdentity(external.Issuer, external.Subject)
+
+	switch {
+	case err == nil:
+		return user, nil
+	case !errors.Is(err, common.ErrNotFound):
+		return nil, err
+	}
+
+	if external.Email == "" {
+		return nil, ErrMissingEmail
+	}
+
+	identity := &Identity{
+		ID:      uuid.New(),
+		Issuer:  external.Issuer,
+		Subject: external.Subject,
+		Email:   normalizeEmail(external.Email),
+	}
+
+	user, err = s.repo.FindByEmail(identity.Email)
+
+	switch {
+	case err == nil:
+		if !external.EmailVerified || user.EmailVerifiedAt == nil {
+			return nil, ErrDuplicateEmail
+		}
+
+		identity.UserID = user.ID
+
+		_, err = s.repo.SaveIdentity(identity)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	case !errors.Is(err, common.ErrNotFound):
+		return nil, err
+	}
+
+	name := strings.TrimSpace(external.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+
+	newUser := &User{
+		ID:    uuid.New(),
+		Email: identity.Email,
+		Name:  name,
+		Role:  RoleReader,
+	}
+
+	if external.EmailVerified {
+		now := time.Now()
+		newUser.EmailVerifiedAt = &now
+	}
+
+	return s.repo.SaveWithIdentity(newUser, identity)
+}
+
+// LinkExternal links an account at an identity provider to a logged in user,
+// whatever its email address. An account that is linked to another user
+// yields ErrIdentityLinked.
+func (s *userService) LinkExternal(id string, external *ExternalUser) (*User, error) {
+	linked, err := s.repo.FindByIdentity(external.Issuer, external.Subject)
+
+	switch {
+	case err == nil:
+		if linked.ID.String() != id {
+			return nil, ErrIdentityLinked
+		}
+
+		return linked, nil
+	case !errors.Is(err, common.ErrNotFound):
+		return nil, err
+	}
+
+	_, err = s.repo.SaveIdentity(&Identity{
+		ID:      uuid.New(),
+		UserID:  uuid.MustParse(id),
+		Issuer:  external.Issuer,
+		Subject: external.Subject,
+		Email:   normalizeEmail(external.Email),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
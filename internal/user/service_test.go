@@ -0,0 +1,361 @@
+//go:build unit
+// +build unit
+
+package user
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/contentfilter"
+	"github.com/jakottelaar/gobookreviewapp/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestUserService returns a service that writes its emails to out.
+func newTestUserService(repo UserRepository, tokens TokenRepository, out io.Writer) UserService {
+	return NewUserService(repo, tokens, mailer.NewWriterMailer(out, "Book Reviews <no-reply@example.com>"), "http://localhost:8080/", contentfilter.NewWordlistFilter([]contentfilter.Entry{
+		{Term: "idiot", Action: contentfilter.ActionMask},
+		{Term: "cheap pills", Action: contentfilter.ActionReject, Category: "spam"},
+	}))
+}
+
+func TestRegisterUserService(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockTokenRepository)
+	var sent bytes.Buffer
+	service := newTestUserService(mockRepo, mockTokens, &sent)
+
+	t.Run("Register user service: Successfully register a user", func(t *testing.T) {
+		req := &RegisterRequest{
+			Email:    " Jane.Doe@Example.com ",
+			Name:     "Jane Doe",
+			Password: "correct-Horse-battery-5taple",
+		}
+
+		mockRepo.On("Save", mock.MatchedBy(func(user *User) bool {
+			return user.Email == "jane.doe@example.com" &&
+				bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(req.Password)) == nil
+		})).Return(&User{ID: uuid.New(), Email: "jane.doe@example.com", Name: "Jane Doe", CreatedAt: time.Now()}, nil).Once()
+		mockTokens.On("CountSince", mock.Anything, TokenPurposeVerifyEmail, mock.Anything).Return(0, nil).Once()
+		mockTokens.On("Save", mock.MatchedBy(func(token *Token) bool {
+			return token.Purpose == TokenPurposeVerifyEmail
+		})).Return(&Token{}, nil).Once()
+
+		result, err := service.Register(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "jane.doe@example.com", result.Email)
+		assert.Contains(t, sent.String(), "To: jane.doe@example.com")
+		assert.Contains(t, sent.String(), "Subject: Verify your email address")
+		mockRepo.AssertExpectations(t)
+		mockTokens.AssertExpectations(t)
+	})
+
+	t.Run("Register user service: Email already registered", func(t *testing.T) {
+		req := &RegisterRequest{
+			Email:    "jane.doe@example.com",
+			Name:     "Jane Doe",
+			Password: "correct-Horse-battery-5taple",
+		}
+
+		mockRepo.On("Save", mock.AnythingOfType("*user.User")).Return((*User)(nil), ErrDuplicateEmail).Once()
+
+		result, err := service.Register(req)
+
+		require.ErrorIs(t, err, ErrDuplicateEmail)
+		assert.Nil(t, result)
+		mockRepo.AssertExpectations(t)
+		mockTokens.AssertNumberOfCalls(t, "Save", 1)
+	})
+}
+
+func TestLoginUserService(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-Horse-battery-5taple"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	existing := &User{ID: uuid.New(), Email: "jane.doe@example.com", Name: "Jane Doe", PasswordHash: hash}
+
+	mockRepo.On("FindByEmail", "jane.doe@example.com").Return(existing, nil)
+	mockRepo.On("FindByEmail", "john.doe@example.com").Return((*User)(nil), common.ErrNotFound)
+	mockRepo.On("FindByEmail", "sso.user@example.com").Return(&User{ID: uuid.New(), Email: "sso.user@example.com"}, nil)
+
+	t.Run("Login user service: Successfully log in", func(t *testing.T) {
+		result, err := service.Login(&LoginRequest{Email: "Jane.Doe@example.com", Password: "correct-Horse-battery-5taple"})
+
+		require.NoError(t, err)
+		assert.Equal(t, existing, result)
+	})
+
+	t.Run("Login user service: Wrong password", func(t *testing.T) {
+		result, err := service.Login(&LoginRequest{Email: "jane.doe@example.com", Password: "wrong-Password-1"})
+
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Login user service: Unknown email", func(t *testing.T) {
+		result, err := service.Login(&LoginRequest{Email: "john.doe@example.com", Password: "correct-Horse-battery-5taple"})
+
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Login user service: User without a password", func(t *testing.T) {
+		result, err := service.Login(&LoginRequest{Email: "sso.user@example.com", Password: ""})
+
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Nil(t, result)
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLoginExternalUserService(t *testing.T) {
+	const issuer = "https://sso.example.com"
+
+	t.Run("Login external user service: Linked identity", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		linked := &User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+		mockRepo.On("FindByIdentity", issuer, "42").Return(linked, nil)
+
+		result, err := service.LoginExternal(&ExternalUser{Issuer: issuer, Subject: "42", Email: "jane@example.org"})
+
+		require.NoError(t, err)
+		assert.Equal(t, linked, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Login external user service: Links a verified email address", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		verifiedAt := time.Now()
+		existing := &User{ID: uuid.New(), Email: "jane.doe@example.com", EmailVerifiedAt: &verifiedAt}
+
+		mockRepo.On("FindByIdentity", issuer, "42").Return((*User)(nil), common.ErrNotFound)
+		mockRepo.On("FindByEmail", "jane.doe@example.com").Return(existing, nil)
+		mockRepo.On("SaveIdentity", mock.MatchedBy(func(identity *Identity) bool {
+			return identity.UserID == existing.ID && identity.Issuer == issuer && identity.Subject == "42"
+		})).Return(&Identity{}, nil)
+
+		result, err := service.LoginExternal(&ExternalUser{Issuer: issuer, Subject: "42", Email: "Jane.Doe@example.com", EmailVerified: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, existing, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Login external user service: Account with an unverified email address is not linked", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		// Anyone could have registered the address with a password of their own
+		mockRepo.On("FindByIdentity", issuer, "42").Return((*User)(nil), common.ErrNotFound)
+		mockRepo.On("FindByEmail", "jane.doe@example.com").Return(&User{ID: uuid.New(), Email: "jane.doe@example.com", PasswordHash: []byte("hash")}, nil)
+
+		result, err := service.LoginExternal(&ExternalUser{Issuer: issuer, Subject: "42", Email: "jane.doe@example.com", EmailVerified: true})
+
+		require.ErrorIs(t, err, ErrDuplicateEmail)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "SaveIdentity", mock.Anything)
+		mockRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything)
+	})
+
+	t.Run("Login external user service: Unverified email address is already registered", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		mockRepo.On("FindByIdentity", issuer, "42").Return((*User)(nil), common.ErrNotFound)
+		mockRepo.On("FindByEmail", "jane.doe@example.com").Return(&User{ID: uuid.New()}, nil)
+
+		result, err := service.LoginExternal(&ExternalUser{Issuer: issuer, Subject: "42", Email: "jane.doe@example.com"})
+
+		require.ErrorIs(t, err, ErrDuplicateEmail)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "SaveIdentity", mock.Anything)
+	})
+
+	t.Run("Login external user service: Provisions a new user", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		mockRepo.On("FindByIdentity", issuer, "42").Return((*User)(nil), common.ErrNotFound)
+		mockRepo.On("FindByEmail", "jane.doe@example.com").Return((*User)(nil), common.ErrNotFound)
+		mockRepo.On("SaveWithIdentity", mock.MatchedBy(func(user *User) bool {
+			return user.Email == "jane.doe@example.com" && user.Name == "jane.doe" && user.Role == RoleReader && user.PasswordHash == nil
+		}), mock.MatchedBy(func(identity *Identity) bool {
+			return identity.Issuer == issuer && identity.Subject == "42"
+		})).Return(&User{ID: uuid.New(), Role: RoleReader}, nil)
+
+		result, err := service.LoginExternal(&ExternalUser{Issuer: issuer, Subject: "42", Email: "jane.doe@example.com"})
+
+		require.NoError(t, err)
+		assert.Equal(t, RoleReader, result.Role)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Login external user service: No email address", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		mockRepo.On("FindByIdentity", issuer, "42").Return((*User)(nil), common.ErrNotFound)
+
+		result, err := service.LoginExternal(&ExternalUser{Issuer: issuer, Subject: "42"})
+
+		require.ErrorIs(t, err, ErrMissingEmail)
+		assert.Nil(t, result)
+	})
+}
+
+func TestLinkExternalUserService(t *testing.T) {
+	const issuer = "https://sso.example.com"
+
+	id := uuid.New()
+
+	t.Run("Link external user service: Link an account", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		u := &User{ID: id, Email: "jane.doe@example.com"}
+
+		mockRepo.On("FindByIdentity", issuer, "42").Return((*User)(nil), common.ErrNotFound)
+		mockRepo.On("SaveIdentity", mock.MatchedBy(func(identity *Identity) bool {
+			return identity.UserID == id && identity.Issuer == issuer && identity.Subject == "42" && identity.Email == "jane@example.org"
+		})).Return(&Identity{}, nil).Once()
+		mockRepo.On("FindById", id.String()).Return(u, nil)
+
+		result, err := service.LinkExternal(id.String(), &ExternalUser{Issuer: issuer, Subject: "42", Email: "Jane@example.org"})
+
+		require.NoError(t, err)
+		assert.Equal(t, u, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Link external user service: Account linked to another user", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		mockRepo.On("FindByIdentity", issuer, "42").Return(&User{ID: uuid.New()}, nil)
+
+		result, err := service.LinkExternal(id.String(), &ExternalUser{Issuer: issuer, Subject: "42", Email: "jane@example.org"})
+
+		require.ErrorIs(t, err, ErrIdentityLinked)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "SaveIdentity", mock.Anything)
+	})
+}
+
+func TestUpdateProfileUserService(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+	id := uuid.New()
+	updated := &Profile{UserID: id, DisplayName: "Jane", HideActivity: true}
+
+	mockRepo.On("UpdateProfile", &Profile{UserID: id, DisplayName: "Jane", Bio: "Reads mostly classics.", HideActivity: true}).Return(nil).Once()
+	mockRepo.On("FindProfile", id.String()).Return(updated, nil).Once()
+
+	result, err := service.UpdateProfile(id.String(), &UpdateProfileRequest{DisplayName: "  Jane ", Bio: "Reads mostly classics.\n", HideActivity: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, updated, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateProfileContentFilter(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("Update profile user service: Masked bio", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		mockRepo.On("UpdateProfile", &Profile{UserID: id, DisplayName: "Jane", Bio: "Not an *****."}).Return(nil).Once()
+		mockRepo.On("FindProfile", id.String()).Return(&Profile{UserID: id}, nil).Once()
+
+		_, err := service.UpdateProfile(id.String(), &UpdateProfileRequest{DisplayName: "Jane", Bio: "Not an 1d10t."})
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update profile user service: Rejected display name", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		_, err := service.UpdateProfile(id.String(), &UpdateProfileRequest{DisplayName: "Cheap Pills"})
+
+		var rejectedErr *contentfilter.RejectedError
+		require.ErrorAs(t, err, &rejectedErr)
+		assert.Contains(t, rejectedErr.Fields, "DisplayName")
+		mockRepo.AssertNotCalled(t, "UpdateProfile", mock.Anything)
+	})
+}
+
+func TestUpdateProfileValidation(t *testing.T) {
+	validate := NewValidator()
+
+	tests := []struct {
+		avatarURL string
+		valid     bool
+	}{
+		{"", true},
+		{"https://example.com/avatars/jane.png", true},
+		{"http://example.com/avatars/jane.png", false},
+		{"javascript:alert(1)", false},
+		{"not a url", false},
+	}
+
+	for _, tt := range tests {
+		err := validate.Struct(UpdateProfileRequest{AvatarURL: tt.avatarURL})
+
+		if tt.valid {
+			assert.NoError(t, err, tt.avatarURL)
+		} else {
+			assert.Error(t, err, tt.avatarURL)
+		}
+	}
+}
+
+func TestPasswordValidation(t *testing.T) {
+	validate := NewValidator()
+
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"correct-Horse-battery-5taple", true},
+		{"Password1", true},
+		{"password1!", true},
+		{"password", false},
+		{"password1", false},
+		{"PASSWORD", false},
+		{"Pass1!", false},
+		{"Aa1" + string(make([]byte, 70)), false},
+	}
+
+	for _, tt := range tests {
+		req := RegisterRequest{Email: "jane.doe@example.com", Name: "Jane Doe", Password: tt.password}
+
+		err := validate.Struct(req)
+
+		if tt.valid {
+			assert.NoError(t, err, tt.password)
+		} else {
+			assert.Error(t, err, tt.password)
+		}
+	}
+}
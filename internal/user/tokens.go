@@ -0,0 +1,196 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/mailer"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	verifyEmailTTL   = 24 * time.Hour
+	passwordResetTTL = time.Hour
+	// tokenRateLimit is how many tokens of one purpose a user can be sent per
+	// hour.
+	tokenRateLimit = 3
+)
+
+//go:embed templates
+var templates embed.FS
+
+// tokenEmail is the data of the emails that carry a token.
+type tokenEmail struct {
+	Name     string
+	URL      string
+	ValidFor string
+}
+
+// SendVerificationEmail sends a new verification link to the email address
+// of a user.
+func (s *userService) SendVerificationEmail(id string) error {
+	user, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+
+	return s.sendToken(user, TokenPurposeVerifyEmail)
+}
+
+func (s *userService) VerifyEmail(token string) (*User, error) {
+	t, err := s.consumeToken(token, TokenPurposeVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.MarkEmailVerified(t.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(t.UserID.String())
+}
+
+// RequestPasswordReset sends a password reset link to the given address if
+// it belongs to a user. Unknown addresses and users that asked too often are
+// ignored without an error, so the response does not tell whether an
+// address is registered.
+func (s *userService) RequestPasswordReset(email string) error {
+	user, err := s.repo.FindByEmail(normalizeEmail(email))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	err = s.sendToken(user, TokenPurposePasswordReset)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTooManyRequests):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password for the user a reset token was sent to.
+// Any other reset tokens of the user stop working.
+func (s *userService) ResetPassword(req *ResetPasswordRequest) (*User, error) {
+	t, err := s.consumeToken(req.Token, TokenPurposePasswordReset)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.cost)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.UpdatePassword(t.UserID.String(), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokens.InvalidateAll(t.UserID.String(), TokenPurposePasswordReset)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(t.UserID.String())
+}
+
+// sendToken creates a token for purpose and emails a link with it to the
+// user, unless the user has been sent too many tokens in the past hour.
+func (s *userService) sendToken(user *User, purpose string) error {
+	count, err := s.tokens.CountSince(user.ID.String(), purpose, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if count >= tokenRateLimit {
+		return ErrTooManyRequests
+	}
+
+	plain, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	ttl, path, validFor := verifyEmailTTL, "/verify-email", "24 hours"
+	if purpose == TokenPurposePasswordReset {
+		ttl, path, validFor = passwordResetTTL, "/reset-password", "1 hour"
+	}
+
+	_, err = s.tokens.Save(&Token{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(plain),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.NewMessage(templates, "templates/"+purpose+".tmpl", user.Email, tokenEmail{
+		Name:     user.Name,
+		URL:      s.appURL + path + "?token=" + url.QueryEscape(plain),
+		ValidFor: validFor,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(msg)
+}
+
+func (s *userService) consumeToken(plain, purpose string) (*Token, error) {
+	t, err := s.tokens.Consume(hashToken(plain), purpose)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return nil, ErrInvalidToken
+		default:
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// generateToken returns 32 random bytes encoded for use in URLs.
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
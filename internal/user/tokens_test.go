@@ -0,0 +1,196 @@
+//go:build unit
+// +build unit
+
+package user
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSendVerificationEmailUserService(t *testing.T) {
+	t.Run("Send verification email user service: Successfully send an email", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		var sent bytes.Buffer
+		service := newTestUserService(mockRepo, mockTokens, &sent)
+
+		u := &User{ID: uuid.New(), Email: "jane.doe@example.com", Name: "Jane Doe"}
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockTokens.On("CountSince", u.ID.String(), TokenPurposeVerifyEmail, mock.AnythingOfType("time.Time")).Return(2, nil)
+		mockTokens.On("Save", mock.MatchedBy(func(token *Token) bool {
+			return token.UserID == u.ID &&
+				token.Purpose == TokenPurposeVerifyEmail &&
+				len(token.TokenHash) == 32 &&
+				token.ExpiresAt.After(time.Now().Add(23*time.Hour))
+		})).Return(&Token{}, nil)
+
+		err := service.SendVerificationEmail(u.ID.String())
+
+		require.NoError(t, err)
+		assert.Contains(t, sent.String(), "To: jane.doe@example.com")
+		assert.Contains(t, sent.String(), "localhost:8080/verify-email?token")
+		mockTokens.AssertExpectations(t)
+	})
+
+	t.Run("Send verification email user service: Already verified", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		service := newTestUserService(mockRepo, mockTokens, io.Discard)
+
+		verifiedAt := time.Now()
+		u := &User{ID: uuid.New(), EmailVerifiedAt: &verifiedAt}
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+
+		err := service.SendVerificationEmail(u.ID.String())
+
+		require.ErrorIs(t, err, ErrAlreadyVerified)
+		mockTokens.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("Send verification email user service: Too many emails", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		var sent bytes.Buffer
+		service := newTestUserService(mockRepo, mockTokens, &sent)
+
+		u := &User{ID: uuid.New()}
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockTokens.On("CountSince", u.ID.String(), TokenPurposeVerifyEmail, mock.Anything).Return(tokenRateLimit, nil)
+
+		err := service.SendVerificationEmail(u.ID.String())
+
+		require.ErrorIs(t, err, ErrTooManyRequests)
+		assert.Empty(t, sent.String())
+		mockTokens.AssertNotCalled(t, "Save", mock.Anything)
+	})
+}
+
+func TestVerifyEmailUserService(t *testing.T) {
+	t.Run("Verify email user service: Successfully verify an email address", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		service := newTestUserService(mockRepo, mockTokens, io.Discard)
+
+		verifiedAt := time.Now()
+		u := &User{ID: uuid.New(), EmailVerifiedAt: &verifiedAt}
+
+		mockTokens.On("Consume", hashToken("token"), TokenPurposeVerifyEmail).Return(&Token{UserID: u.ID}, nil)
+		mockRepo.On("MarkEmailVerified", u.ID.String()).Return(nil)
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+
+		result, err := service.VerifyEmail("token")
+
+		require.NoError(t, err)
+		assert.Equal(t, u, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Verify email user service: Invalid token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		service := newTestUserService(mockRepo, mockTokens, io.Discard)
+
+		mockTokens.On("Consume", hashToken("token"), TokenPurposeVerifyEmail).Return((*Token)(nil), common.ErrNotFound)
+
+		result, err := service.VerifyEmail("token")
+
+		require.ErrorIs(t, err, ErrInvalidToken)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything)
+	})
+}
+
+func TestRequestPasswordResetUserService(t *testing.T) {
+	t.Run("Request password reset user service: Successfully send an email", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		var sent bytes.Buffer
+		service := newTestUserService(mockRepo, mockTokens, &sent)
+
+		u := &User{ID: uuid.New(), Email: "jane.doe@example.com", Name: "Jane Doe"}
+
+		mockRepo.On("FindByEmail", "jane.doe@example.com").Return(u, nil)
+		mockTokens.On("CountSince", u.ID.String(), TokenPurposePasswordReset, mock.Anything).Return(0, nil)
+		mockTokens.On("Save", mock.MatchedBy(func(token *Token) bool {
+			return token.Purpose == TokenPurposePasswordReset && token.ExpiresAt.Before(time.Now().Add(time.Hour+time.Minute))
+		})).Return(&Token{}, nil)
+
+		err := service.RequestPasswordReset(" Jane.Doe@example.com")
+
+		require.NoError(t, err)
+		assert.Contains(t, sent.String(), "Subject: Reset your password")
+		assert.Contains(t, sent.String(), "localhost:8080/reset-password?token")
+		mockTokens.AssertExpectations(t)
+	})
+
+	t.Run("Request password reset user service: Unknown email and too many emails are ignored", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		var sent bytes.Buffer
+		service := newTestUserService(mockRepo, mockTokens, &sent)
+
+		u := &User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+		mockRepo.On("FindByEmail", "john.doe@example.com").Return((*User)(nil), common.ErrNotFound)
+		mockRepo.On("FindByEmail", "jane.doe@example.com").Return(u, nil)
+		mockTokens.On("CountSince", u.ID.String(), TokenPurposePasswordReset, mock.Anything).Return(tokenRateLimit, nil)
+
+		require.NoError(t, service.RequestPasswordReset("john.doe@example.com"))
+		require.NoError(t, service.RequestPasswordReset("jane.doe@example.com"))
+
+		assert.Empty(t, sent.String())
+		mockTokens.AssertNotCalled(t, "Save", mock.Anything)
+	})
+}
+
+func TestResetPasswordUserService(t *testing.T) {
+	t.Run("Reset password user service: Successfully reset a password", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		service := newTestUserService(mockRepo, mockTokens, io.Discard)
+
+		u := &User{ID: uuid.New()}
+		req := &ResetPasswordRequest{Token: "token", Password: "new-Horse-battery-5taple"}
+
+		mockTokens.On("Consume", hashToken("token"), TokenPurposePasswordReset).Return(&Token{UserID: u.ID}, nil)
+		mockRepo.On("UpdatePassword", u.ID.String(), mock.MatchedBy(func(hash []byte) bool {
+			return bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) == nil
+		})).Return(nil)
+		mockTokens.On("InvalidateAll", u.ID.String(), TokenPurposePasswordReset).Return(nil)
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+
+		result, err := service.ResetPassword(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, u, result)
+		mockRepo.AssertExpectations(t)
+		mockTokens.AssertExpectations(t)
+	})
+
+	t.Run("Reset password user service: Verification tokens cannot reset passwords", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockTokens := new(MockTokenRepository)
+		service := newTestUserService(mockRepo, mockTokens, io.Discard)
+
+		mockTokens.On("Consume", hashToken("token"), TokenPurposePasswordReset).Return((*Token)(nil), common.ErrNotFound)
+
+		result, err := service.ResetPassword(&ResetPasswordRequest{Token: "token", Password: "new-Horse-battery-5taple"})
+
+		require.ErrorIs(t, err, ErrInvalidToken)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+	})
+}
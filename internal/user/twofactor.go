@@ -0,0 +1,214 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/totp"
+)
+
+const (
+	// twoFactorIssuer names the API in authenticator apps.
+	twoFactorIssuer = "Book Review API"
+	// recoveryCodeCount is how many recovery codes a user gets at a time.
+	recoveryCodeCount = 10
+	// twoFactorMaxAttempts is how many codes can be entered before the user
+	// is locked out for twoFactorLockout. A code from the app has a one in
+	// 1e6 chance to be guessed, so this keeps guessing hopeless.
+	twoFactorMaxAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// BeginTwoFactor starts enrollment in two-factor authentication with a new
+// TOTP secret. Starting again replaces the secret of an unfinished
+// enrollment.
+func (s *userService) BeginTwoFactor(id string) (*TwoFactorEnrollment, error) {
+	user, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.SetTOTPSecret(id, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret: totp.Encode(secret),
+		URI:    totp.URI(twoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user proves
+// the authenticator app works by entering a code. It returns the recovery
+// codes, which are shown only here.
+func (s *userService) ConfirmTwoFactor(id, code string) ([]string, error) {
+	user, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	if user.TOTPSecret == nil {
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.EnableTwoFactor(id, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifyTwoFactor checks the second factor of a user: a code from the
+// authenticator app or an unused recovery code. Either works only once.
+// After twoFactorMaxAttempts codes without a correct one, the user is locked
+// out and ErrTwoFactorLocked is returned until the lockout has passed.
+func (s *userService) VerifyTwoFactor(id, code string) error {
+	user, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotSetUp
+	}
+
+	err = s.repo.ClaimTwoFactorAttempt(id, twoFactorMaxAttempts, twoFactorLockout)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return ErrTwoFactorLocked
+		default:
+			return err
+		}
+	}
+
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return ErrInvalidCode
+		}
+
+		err = s.repo.UseTOTPStep(id, step)
+	} else {
+		err = s.repo.UseRecoveryCode(id, hashRecoveryCode(code))
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrNotFound):
+			return ErrInvalidCode
+		default:
+			return err
+		}
+	}
+
+	return s.repo.ResetTwoFactorAttempts(id)
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking a
+// code. Roles that require it cannot turn it off.
+func (s *userService) DisableTwoFactor(id, code string) error {
+	user, err := s.repo.FindById(id)
+	if err != nil {
+		return err
+	}
+
+	if RequiresTwoFactor(user.Role) {
+		return ErrTwoFactorRequired
+	}
+
+	err = s.VerifyTwoFactor(id, code)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DisableTwoFactor(id)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user after
+// checking a code, for when they have been used up or were lost.
+func (s *userService) RegenerateRecoveryCodes(id, code string) ([]string, error) {
+	err := s.VerifyTwoFactor(id, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.ReplaceRecoveryCodes(id, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCodes returns new recovery codes, such as "k3v9q-2mxta",
+// and their hashes. The codes have 50 bits of entropy each, so a fast hash
+// is enough.
+func generateRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([][]byte, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 7)
+
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b)[:10])
+
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code ignoring case, spaces and dashes,
+// so codes can be typed in however they were written down.
+func hashRecoveryCode(code string) []byte {
+	code = strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+
+	hash := sha256.Sum256([]byte(code))
+	return hash[:]
+}
@@ -0,0 +1,254 @@
+//go:build unit
+// +build unit
+
+package user
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/common"
+	"github.com/jakottelaar/gobookreviewapp/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeginTwoFactorUserService(t *testing.T) {
+	t.Run("Begin two-factor user service: Store a new secret", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		u := &User{ID: uuid.New(), Email: "jane.doe@example.com"}
+
+		var secret []byte
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockRepo.On("SetTOTPSecret", u.ID.String(), mock.AnythingOfType("[]uint8")).Run(func(args mock.Arguments) {
+			secret = args.Get(1).([]byte)
+		}).Return(nil)
+
+		enrollment, err := service.BeginTwoFactor(u.ID.String())
+
+		require.NoError(t, err)
+		assert.Len(t, secret, 20)
+		assert.Equal(t, totp.Encode(secret), enrollment.Secret)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/")
+		assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+	})
+
+	t.Run("Begin two-factor user service: Already enabled", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		enabledAt := time.Now()
+		u := &User{ID: uuid.New(), TOTPSecret: []byte("secret"), TwoFactorEnabledAt: &enabledAt}
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+
+		_, err := service.BeginTwoFactor(u.ID.String())
+
+		require.ErrorIs(t, err, ErrTwoFactorEnabled)
+		mockRepo.AssertNotCalled(t, "SetTOTPSecret", mock.Anything, mock.Anything)
+	})
+}
+
+func TestConfirmTwoFactorUserService(t *testing.T) {
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+
+	t.Run("Confirm two-factor user service: Enable with recovery codes", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		u := &User{ID: uuid.New(), TOTPSecret: secret}
+		step := totp.Step(time.Now())
+
+		var hashes [][]byte
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockRepo.On("EnableTwoFactor", u.ID.String(), step, mock.Anything).Run(func(args mock.Arguments) {
+			hashes = args.Get(2).([][]byte)
+		}).Return(nil)
+
+		codes, err := service.ConfirmTwoFactor(u.ID.String(), totp.Code(secret, step))
+
+		require.NoError(t, err)
+		require.Len(t, codes, recoveryCodeCount)
+		require.Len(t, hashes, recoveryCodeCount)
+
+		for i, code := range codes {
+			assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+			assert.Equal(t, hashRecoveryCode(code), hashes[i])
+		}
+	})
+
+	t.Run("Confirm two-factor user service: Wrong code", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		u := &User{ID: uuid.New(), TOTPSecret: secret}
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+
+		_, err := service.ConfirmTwoFactor(u.ID.String(), totp.Code(secret, totp.Step(time.Now())-5))
+
+		require.ErrorIs(t, err, ErrInvalidCode)
+		mockRepo.AssertNotCalled(t, "EnableTwoFactor", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Confirm two-factor user service: Not set up", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		u := &User{ID: uuid.New()}
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+
+		_, err := service.ConfirmTwoFactor(u.ID.String(), "123456")
+
+		require.ErrorIs(t, err, ErrTwoFactorNotSetUp)
+	})
+}
+
+func TestVerifyTwoFactorUserService(t *testing.T) {
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+
+	enabledAt := time.Now()
+	u := &User{ID: uuid.New(), Role: RoleReader, TOTPSecret: secret, TwoFactorEnabledAt: &enabledAt}
+
+	t.Run("Verify two-factor user service: Code from the authenticator app", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		step := totp.Step(time.Now())
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockRepo.On("ClaimTwoFactorAttempt", u.ID.String(), twoFactorMaxAttempts, twoFactorLockout).Return(nil)
+		mockRepo.On("ResetTwoFactorAttempts", u.ID.String()).Return(nil)
+		mockRepo.On("UseTOTPStep", u.ID.String(), step).Return(nil).Once()
+
+		require.NoError(t, service.VerifyTwoFactor(u.ID.String(), totp.Code(secret, step)))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Verify two-factor user service: Code used before", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		step := totp.Step(time.Now())
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockRepo.On("ClaimTwoFactorAttempt", u.ID.String(), twoFactorMaxAttempts, twoFactorLockout).Return(nil)
+		mockRepo.On("ResetTwoFactorAttempts", u.ID.String()).Return(nil)
+		mockRepo.On("UseTOTPStep", u.ID.String(), step).Return(common.ErrNotFound)
+
+		err := service.VerifyTwoFactor(u.ID.String(), totp.Code(secret, step))
+
+		require.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("Verify two-factor user service: Recovery code", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockRepo.On("ClaimTwoFactorAttempt", u.ID.String(), twoFactorMaxAttempts, twoFactorLockout).Return(nil)
+		mockRepo.On("ResetTwoFactorAttempts", u.ID.String()).Return(nil)
+		mockRepo.On("UseRecoveryCode", u.ID.String(), hashRecoveryCode("k3v9q-2mxta")).Return(nil).Once()
+		mockRepo.On("UseRecoveryCode", u.ID.String(), mock.Anything).Return(common.ErrNotFound)
+
+		require.NoError(t, service.VerifyTwoFactor(u.ID.String(), " K3V9Q2MXTA "))
+
+		err := service.VerifyTwoFactor(u.ID.String(), "k3v9q-2mxta")
+		require.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("Verify two-factor user service: Locked out after too many codes", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockRepo.On("ClaimTwoFactorAttempt", u.ID.String(), twoFactorMaxAttempts, twoFactorLockout).Return(common.ErrNotFound)
+
+		// Even the right code is not checked while locked out
+		err := service.VerifyTwoFactor(u.ID.String(), totp.Code(secret, totp.Step(time.Now())))
+
+		require.ErrorIs(t, err, ErrTwoFactorLocked)
+		mockRepo.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Verify two-factor user service: Not enabled", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		pending := &User{ID: uuid.New(), TOTPSecret: secret}
+
+		mockRepo.On("FindById", pending.ID.String()).Return(pending, nil)
+
+		err := service.VerifyTwoFactor(pending.ID.String(), totp.Code(secret, totp.Step(time.Now())))
+
+		require.ErrorIs(t, err, ErrTwoFactorNotSetUp)
+	})
+}
+
+func TestDisableTwoFactorUserService(t *testing.T) {
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+
+	enabledAt := time.Now()
+
+	t.Run("Disable two-factor user service: Successfully disable", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		u := &User{ID: uuid.New(), Role: RoleEditor, TOTPSecret: secret, TwoFactorEnabledAt: &enabledAt}
+		step := totp.Step(time.Now())
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+		mockRepo.On("ClaimTwoFactorAttempt", u.ID.String(), twoFactorMaxAttempts, twoFactorLockout).Return(nil)
+		mockRepo.On("ResetTwoFactorAttempts", u.ID.String()).Return(nil)
+		mockRepo.On("UseTOTPStep", u.ID.String(), step).Return(nil)
+		mockRepo.On("DisableTwoFactor", u.ID.String()).Return(nil).Once()
+
+		require.NoError(t, service.DisableTwoFactor(u.ID.String(), totp.Code(secret, step)))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Disable two-factor user service: Required for the role", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+		u := &User{ID: uuid.New(), Role: RoleModerator, TOTPSecret: secret, TwoFactorEnabledAt: &enabledAt}
+
+		mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+
+		err := service.DisableTwoFactor(u.ID.String(), totp.Code(secret, totp.Step(time.Now())))
+
+		require.ErrorIs(t, err, ErrTwoFactorRequired)
+		mockRepo.AssertNotCalled(t, "DisableTwoFactor", mock.Anything)
+	})
+}
+
+func TestRegenerateRecoveryCodesUserService(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo, new(MockTokenRepository), io.Discard)
+
+	enabledAt := time.Now()
+	u := &User{ID: uuid.New(), TOTPSecret: []byte("secret"), TwoFactorEnabledAt: &enabledAt}
+
+	mockRepo.On("FindById", u.ID.String()).Return(u, nil)
+	mockRepo.On("ClaimTwoFactorAttempt", u.ID.String(), twoFactorMaxAttempts, twoFactorLockout).Return(nil)
+	mockRepo.On("ResetTwoFactorAttempts", u.ID.String()).Return(nil)
+	mockRepo.On("UseRecoveryCode", u.ID.String(), hashRecoveryCode("k3v9q-2mxta")).Return(nil)
+	mockRepo.On("ReplaceRecoveryCodes", u.ID.String(), mock.MatchedBy(func(hashes [][]byte) bool {
+		return len(hashes) == recoveryCodeCount
+	})).Return(nil).Once()
+
+	codes, err := service.RegenerateRecoveryCodes(u.ID.String(), "k3v9q-2mxta")
+
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	mockRepo.AssertExpectations(t)
+}
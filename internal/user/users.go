@@ -0,0 +1,264 @@
+package user
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleEditor    = "editor"
+	RoleReader    = "reader"
+)
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposePasswordReset = "password_reset"
+)
+
+// twoFactorRoles are the roles that must use two-factor authentication,
+// because they can delete the catalog or manage users.
+var twoFactorRoles = map[string]bool{
+	RoleAdmin:     true,
+	RoleModerator: true,
+}
+
+// RequiresTwoFactor reports whether users with role must enable two-factor
+// authentication.
+func RequiresTwoFactor(role string) bool {
+	return twoFactorRoles[role]
+}
+
+var (
+	ErrDuplicateEmail      = errors.New("a user with this email address already exists")
+	ErrInvalidCredentials  = errors.New("invalid email address or password")
+	ErrMissingEmail        = errors.New("the identity provider did not share an email address")
+	ErrIdentityLinked      = errors.New("the account at the identity provider is linked to another user")
+	ErrInvalidToken        = errors.New("invalid or expired token")
+	ErrAlreadyVerified     = errors.New("the email address has already been verified")
+	ErrTooManyRequests     = errors.New("too many requests, please try again later")
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotSetUp   = errors.New("two-factor authentication has not been set up")
+	ErrInvalidCode         = errors.New("invalid or already used code")
+	ErrTwoFactorLocked     = errors.New("too many invalid codes, please try again later")
+	ErrTwoFactorRequired   = errors.New("two-factor authentication is required for your role")
+	ErrDeletionScheduled   = errors.New("the account is already scheduled for deletion")
+	ErrNoDeletionScheduled = errors.New("the account is not scheduled for deletion")
+)
+
+type User struct {
+	ID              uuid.UUID
+	Email           string
+	Name            string
+	Role            string
+	PasswordHash    []byte
+	EmailVerifiedAt *time.Time
+	// TOTPSecret is set once enrollment in two-factor authentication has
+	// started; TwoFactorEnabledAt once it has been confirmed.
+	TOTPSecret         []byte
+	TwoFactorEnabledAt *time.Time
+	// DeletionScheduledAt is when the account will be deleted, if the user
+	// asked for that.
+	DeletionScheduledAt *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// TwoFactorEnabled reports whether the user must enter a code after their
+// password.
+func (u *User) TwoFactorEnabled() bool {
+	return u.TwoFactorEnabledAt != nil
+}
+
+// TwoFactorEnrollment is a new TOTP secret to add to an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// Profile is what other users see of a user.
+type Profile struct {
+	UserID      uuid.UUID
+	Name        string
+	DisplayName string
+	Bio         string
+	AvatarURL   string
+	// HideActivity hides the stats of the user from other users.
+	HideActivity bool
+	// DeletionScheduled hides the profile while the account is about to be
+	// deleted.
+	DeletionScheduled bool
+	Stats             ProfileStats
+	CreatedAt         time.Time
+}
+
+// ProfileStats counts the reading activity of a user.
+type ProfileStats struct {
+	ReviewCount int
+	BooksRead   int
+}
+
+// Token is a single use token sent by email to verify an email address or
+// to reset a password. Only a hash of the token is kept.
+type Token struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   string
+	TokenHash []byte
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Identity links a user to an account at an external identity provider.
+type Identity struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Issuer    string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// ExternalUser is a user as asserted by an external identity provider.
+type ExternalUser struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255" example:"jane.doe@example.com"`
+	Name     string `json:"name" validate:"required,max=100" example:"Jane Doe"`
+	Password string `json:"password" validate:"required,min=8,password" example:"correct-Horse-battery-5taple"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email" example:"jane.doe@example.com"`
+	Password string `json:"password" validate:"required" example:"correct-Horse-battery-5taple"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email" example:"jane.doe@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,password" example:"correct-Horse-battery-5taple"`
+}
+
+// TwoFactorCodeRequest carries a code from an authenticator app or, where
+// accepted, a recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=32" example:"123456"`
+}
+
+// UpdateProfileRequest replaces the profile of the current user. Avatars are
+// linked rather than uploaded, and must be served over HTTPS.
+type UpdateProfileRequest struct {
+	DisplayName  string `json:"display_name" validate:"max=100" example:"Jane"`
+	Bio          string `json:"bio" validate:"max=500" example:"Reads mostly classics and the occasional thriller."`
+	AvatarURL    string `json:"avatar_url" validate:"omitempty,max=2048,url,startswith=https://" example:"https://example.com/avatars/jane.png"`
+	HideActivity bool   `json:"hide_activity" example:"false"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin moderator editor reader" example:"editor"`
+}
+
+type UserResponse struct {
+	ID            string `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	Email         string `json:"email" example:"jane.doe@example.com"`
+	EmailVerified bool   `json:"email_verified" example:"true"`
+	TwoFactor     bool   `json:"two_factor_enabled" example:"false"`
+	Name          string `json:"name" example:"Jane Doe"`
+	Role          string `json:"role" example:"reader"`
+	// DeletionScheduledAt is set while the account is about to be deleted.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" example:"2024-02-01T00:00:00Z"`
+	CreatedAt           time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+func newUserResponse(user *User) UserResponse {
+	return UserResponse{
+		ID:                  user.ID.String(),
+		Email:               user.Email,
+		EmailVerified:       user.EmailVerifiedAt != nil,
+		TwoFactor:           user.TwoFactorEnabled(),
+		Name:                user.Name,
+		Role:                user.Role,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		CreatedAt:           user.CreatedAt,
+	}
+}
+
+type ProfileStatsResponse struct {
+	ReviewCount int `json:"review_count" example:"12"`
+	BooksRead   int `json:"books_read" example:"40"`
+}
+
+func newProfileStatsResponse(stats ProfileStats) *ProfileStatsResponse {
+	return &ProfileStatsResponse{
+		ReviewCount: stats.ReviewCount,
+		BooksRead:   stats.BooksRead,
+	}
+}
+
+// PublicProfileResponse is a profile as other users see it. Stats are left
+// out when the user hides their activity.
+type PublicProfileResponse struct {
+	ID          string                `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	DisplayName string                `json:"display_name,omitempty" example:"Jane"`
+	Bio         string                `json:"bio,omitempty" example:"Reads mostly classics and the occasional thriller."`
+	AvatarURL   string                `json:"avatar_url,omitempty" example:"https://example.com/avatars/jane.png"`
+	Stats       *ProfileStatsResponse `json:"stats,omitempty"`
+	MemberSince time.Time             `json:"member_since" example:"2024-01-01T00:00:00Z"`
+}
+
+func newPublicProfileResponse(profile *Profile) PublicProfileResponse {
+	response := PublicProfileResponse{
+		ID:          profile.UserID.String(),
+		DisplayName: profile.DisplayName,
+		Bio:         profile.Bio,
+		AvatarURL:   profile.AvatarURL,
+		MemberSince: profile.CreatedAt,
+	}
+
+	if !profile.HideActivity {
+		response.Stats = newProfileStatsResponse(profile.Stats)
+	}
+
+	return response
+}
+
+// ProfileResponse is the profile of the current user, with its settings. The
+// stats are included even when they are hidden from others.
+type ProfileResponse struct {
+	ID           string               `json:"id" example:"123e4567-e89b-12d3-a456-426614174000" format:"uuid"`
+	DisplayName  string               `json:"display_name" example:"Jane"`
+	Bio          string               `json:"bio" example:"Reads mostly classics and the occasional thriller."`
+	AvatarURL    string               `json:"avatar_url" example:"https://example.com/avatars/jane.png"`
+	HideActivity bool                 `json:"hide_activity" example:"false"`
+	Stats        ProfileStatsResponse `json:"stats"`
+	MemberSince  time.Time            `json:"member_since" example:"2024-01-01T00:00:00Z"`
+}
+
+func newProfileResponse(profile *Profile) ProfileResponse {
+	return ProfileResponse{
+		ID:           profile.UserID.String(),
+		DisplayName:  profile.DisplayName,
+		Bio:          profile.Bio,
+		AvatarURL:    profile.AvatarURL,
+		HideActivity: profile.HideActivity,
+		Stats:        *newProfileStatsResponse(profile.Stats),
+		MemberSince:  profile.CreatedAt,
+	}
+}
@@ -0,0 +1,54 @@
+package user
+
+import (
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// maxPasswordBytes is the longest password bcrypt accepts.
+const maxPasswordBytes = 72
+
+// NewValidator returns a validator with the user specific rules registered.
+func NewValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	_ = validate.RegisterValidation("password", strongPassword)
+
+	return validate
+}
+
+// strongPassword accepts passwords that fit in bcrypt's input and mix at
+// least three of lowercase letters, uppercase letters, digits and other
+// characters.
+func strongPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+
+	if len(password) > maxPasswordBytes {
+		return false
+	}
+
+	var lower, upper, digit, other bool
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+
+	return classes >= 3
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS book_redirects;
\ No newline at end of file
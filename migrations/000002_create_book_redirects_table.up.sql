@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS book_redirects (
+    from_book_id UUID PRIMARY KEY REFERENCES books(id) ON DELETE CASCADE,
+    to_book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create an index on the to_book_id column so chained redirects can be repointed quickly
+CREATE INDEX idx_book_redirects_to_book_id ON book_redirects(to_book_id);
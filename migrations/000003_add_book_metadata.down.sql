@@ -0,0 +1,8 @@
+ALTER TABLE books
+    DROP COLUMN IF EXISTS subtitle,
+    DROP COLUMN IF EXISTS original_title,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS publication_date,
+    DROP COLUMN IF EXISTS page_count,
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS format;
@@ -0,0 +1,13 @@
+ALTER TABLE books
+    ADD COLUMN subtitle VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN original_title VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN publication_date DATE,
+    ADD COLUMN page_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN language VARCHAR(35) NOT NULL DEFAULT '',
+    ADD COLUMN format VARCHAR(20) NOT NULL DEFAULT '';
+
+-- A page count of 0 means the page count is unknown
+ALTER TABLE books
+    ADD CONSTRAINT books_page_count_check CHECK (page_count >= 0),
+    ADD CONSTRAINT books_format_check CHECK (format IN ('', 'hardcover', 'paperback', 'ebook', 'audiobook'));
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS book_translations;
\ No newline at end of file
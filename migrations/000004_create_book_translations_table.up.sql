@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS book_translations (
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    language VARCHAR(35) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (book_id, language)
+);
+
+CREATE TRIGGER update_book_translations_updated_at
+    BEFORE UPDATE ON book_translations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS book_edit_suggestions;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS book_edit_suggestions (
+    id UUID PRIMARY KEY,
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    changes JSONB NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    submitted_by VARCHAR(100) NOT NULL,
+    reviewed_by VARCHAR(100) NOT NULL DEFAULT '',
+    review_comment TEXT NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create an index for the moderation queue, which lists suggestions by status in order of submission
+CREATE INDEX idx_book_edit_suggestions_status_created_at ON book_edit_suggestions(status, created_at);
+
+CREATE INDEX idx_book_edit_suggestions_book_id ON book_edit_suggestions(book_id);
+
+CREATE TRIGGER update_book_edit_suggestions_updated_at
+    BEFORE UPDATE ON book_edit_suggestions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1 @@
+ALTER TABLE book_edit_suggestions DROP COLUMN IF EXISTS flags;
\ No newline at end of file
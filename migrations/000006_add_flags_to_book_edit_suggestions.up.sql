@@ -0,0 +1,3 @@
+-- Reasons the content filter flagged a suggestion for closer moderator review
+ALTER TABLE book_edit_suggestions
+    ADD COLUMN flags TEXT[] NOT NULL DEFAULT '{}';
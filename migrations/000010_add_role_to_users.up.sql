@@ -0,0 +1,3 @@
+-- Roles grant permissions, see internal/auth/permissions.go
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'reader' CHECK (role IN ('admin', 'moderator', 'editor', 'reader'));
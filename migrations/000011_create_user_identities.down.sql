@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_identities;
+
+DELETE FROM users WHERE password_hash IS NULL;
+
+ALTER TABLE users ALTER COLUMN password_hash SET NOT NULL;
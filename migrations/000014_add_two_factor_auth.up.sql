@@ -0,0 +1,17 @@
+-- The TOTP secret is set when enrollment starts; two-factor authentication
+-- is enabled once a code generated with it has been confirmed.
+ALTER TABLE users
+    ADD COLUMN totp_secret BYTEA,
+    ADD COLUMN totp_enabled_at TIMESTAMP WITH TIME ZONE,
+    -- The time step of the last accepted code, so a code cannot be used twice
+    ADD COLUMN totp_last_step BIGINT;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash BYTEA NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_recovery_codes_code_hash ON recovery_codes(user_id, code_hash);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_book_edit_suggestions_submitted_by;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS display_name,
+    DROP COLUMN IF EXISTS bio,
+    DROP COLUMN IF EXISTS avatar_url,
+    DROP COLUMN IF EXISTS hide_activity;
@@ -0,0 +1,10 @@
+-- Profiles are public, so users choose what they show there
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+    ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '',
+    -- Hides the reading stats of the user from others
+    ADD COLUMN hide_activity BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Suggestions are listed and anonymized by their submitter
+CREATE INDEX idx_book_edit_suggestions_submitted_by ON book_edit_suggestions(submitted_by);
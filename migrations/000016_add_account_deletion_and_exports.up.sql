@@ -0,0 +1,22 @@
+-- Accounts are deleted once this time has passed, unless the user cancels
+ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
+
+-- Exports of all data of a user, built in the background as a ZIP archive
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    archive BYTEA,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    -- Exports are deleted after this time, pending ones included in case
+    -- building them was interrupted
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A user has at most one export being built at a time
+CREATE UNIQUE INDEX idx_data_exports_pending ON data_exports(user_id) WHERE status = 'pending';
+
+CREATE INDEX idx_data_exports_expires_at ON data_exports(expires_at);
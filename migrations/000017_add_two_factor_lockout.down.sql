@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_attempts,
+    DROP COLUMN IF EXISTS totp_locked_until;
@@ -0,0 +1,5 @@
+-- Codes entered for the second factor since the last accepted one. Once too
+-- many have been entered, no more are checked until totp_locked_until.
+ALTER TABLE users
+    ADD COLUMN totp_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN totp_locked_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shelf_entries;
+
+DROP TABLE IF EXISTS reviews;
@@ -0,0 +1,33 @@
+-- Reviews of books, at most one per user and book
+CREATE TABLE IF NOT EXISTS reviews (
+    id UUID PRIMARY KEY,
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, book_id)
+);
+
+CREATE INDEX idx_reviews_book_id ON reviews(book_id);
+
+CREATE TRIGGER update_reviews_updated_at
+    BEFORE UPDATE ON reviews
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- The shelf a user keeps a book on
+CREATE TABLE IF NOT EXISTS shelf_entries (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    shelf VARCHAR(20) NOT NULL CHECK (shelf IN ('want_to_read', 'reading', 'read')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, book_id)
+);
+
+CREATE TRIGGER update_shelf_entries_updated_at
+    BEFORE UPDATE ON shelf_entries
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
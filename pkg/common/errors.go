@@ -41,3 +41,41 @@ func BadRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
 func FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
 	errorResponse(w, r, http.StatusUnprocessableEntity, errors)
 }
+
+func ConflictResponse(w http.ResponseWriter, r *http.Request, err error) {
+	errorResponse(w, r, http.StatusConflict, err.Error())
+}
+
+func InvalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
+	message := "invalid authentication credentials"
+	errorResponse(w, r, http.StatusUnauthorized, message)
+}
+
+func InvalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+
+	message := "invalid or missing authentication token"
+	errorResponse(w, r, http.StatusUnauthorized, message)
+}
+
+func AuthenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+
+	message := "you must be authenticated to access this resource"
+	errorResponse(w, r, http.StatusUnauthorized, message)
+}
+
+func NotPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you do not have the necessary permissions to access this resource"
+	errorResponse(w, r, http.StatusForbidden, message)
+}
+
+func TwoFactorRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you must enable two-factor authentication to access this resource"
+	errorResponse(w, r, http.StatusForbidden, message)
+}
+
+func RateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
+	message := "rate limit exceeded"
+	errorResponse(w, r, http.StatusTooManyRequests, message)
+}
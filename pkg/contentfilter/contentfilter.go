@@ -0,0 +1,83 @@
+package contentfilter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Action is what happens to a submission that matches a filter rule. Actions
+// are ordered by severity.
+type Action int
+
+const (
+	ActionAllow Action = iota
+	ActionFlag
+	ActionMask
+	ActionReject
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionAllow:
+		return "allow"
+	case ActionFlag:
+		return "flag"
+	case ActionMask:
+		return "mask"
+	case ActionReject:
+		return "reject"
+	default:
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+}
+
+func ParseAction(s string) (Action, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "flag":
+		return ActionFlag, nil
+	case "mask":
+		return ActionMask, nil
+	case "reject":
+		return ActionReject, nil
+	default:
+		return ActionAllow, fmt.Errorf("unknown content filter action %q", s)
+	}
+}
+
+// Result is the outcome of checking a piece of text.
+type Result struct {
+	// Action is the most severe action of all matched rules.
+	Action Action
+	// Text is the submitted text, with matches masked when Action is
+	// ActionMask.
+	Text string
+	// Reasons lists the categories of the matched rules.
+	Reasons []string
+}
+
+// ContentFilter decides whether user submitted text is acceptable.
+type ContentFilter interface {
+	Check(text string) Result
+}
+
+// RejectedError is returned by services when submitted fields were rejected
+// by the content filter. Fields maps each rejected field to the reason.
+type RejectedError struct {
+	Fields map[string]string
+}
+
+func (e *RejectedError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fmt.Sprintf("content rejected by content policy: %s", strings.Join(fields, ", "))
+}
+
+// Reason formats the reasons of a result for use in a validation response.
+func (r Result) Reason() string {
+	return "violates content policy: " + strings.Join(r.Reasons, ", ")
+}
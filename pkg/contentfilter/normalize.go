@@ -0,0 +1,167 @@
+package contentfilter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// leet maps characters commonly substituted for letters back to the letter.
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+// confusables maps letters from other scripts that look like latin letters
+// to the latin letter. Compatibility forms such as full-width letters are
+// already folded by NFKC before this table is consulted.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's',
+	'і': 'i', 'ї': 'i', 'ј': 'j', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ɡ': 'g',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// Latin look-alikes
+	'ı': 'i', 'ł': 'l', 'ø': 'o', 'đ': 'd', 'ß': 's',
+}
+
+// normalized is text folded for matching. Every rune in runes remembers the
+// byte range of the original text it was derived from, so matches can be
+// masked in the original, whether it was a symbol read as a letter, and how
+// many repeats of it were collapsed into it.
+type normalized struct {
+	runes   []rune
+	spans   [][2]int
+	symbols []bool
+	counts  []int
+}
+
+// normalize lowercases text, folds compatibility forms, diacritics,
+// confusable letters and leet-speak, drops invisible format characters and
+// collapses repeated letters and whitespace.
+func normalize(text string) normalized {
+	var n normalized
+
+	for offset, r := range text {
+		size := utf8.RuneLen(r)
+		if size < 0 {
+			size = 1
+		}
+		span := [2]int{offset, offset + size}
+
+		if unicode.Is(unicode.Cf, r) {
+			continue
+		}
+
+		for _, folded := range norm.NFKD.String(string(r)) {
+			if unicode.Is(unicode.Mn, folded) {
+				continue
+			}
+
+			symbol := !isWordRune(folded)
+			folded = fold(folded)
+
+			last := len(n.runes) - 1
+			if last >= 0 && n.runes[last] == folded && n.symbols[last] == symbol && (isWordRune(folded) || folded == ' ') {
+				n.spans[last][1] = span[1]
+				n.counts[last]++
+				continue
+			}
+
+			n.runes = append(n.runes, folded)
+			n.spans = append(n.spans, span)
+			n.symbols = append(n.symbols, symbol)
+			n.counts = append(n.counts, 1)
+		}
+	}
+
+	return n
+}
+
+func fold(r rune) rune {
+	if unicode.IsSpace(r) {
+		return ' '
+	}
+
+	r = unicode.ToLower(r)
+
+	if c, ok := confusables[r]; ok {
+		return c
+	}
+
+	if l, ok := leet[r]; ok {
+		return l
+	}
+
+	return r
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// normalizeTerm folds a wordlist term the same way as submitted text.
+func normalizeTerm(term string) normalized {
+	return normalize(strings.TrimSpace(term))
+}
+
+// find returns the ranges of n.runes where term occurs as a whole word.
+// Repeated letters only match if the text repeats them at least as often as
+// the term, so "aaass" matches the term "ass" but "as" does not.
+func (n normalized) find(term normalized) [][2]int {
+	var matches [][2]int
+
+	if len(term.runes) == 0 {
+		return nil
+	}
+
+	for start := 0; start+len(term.runes) <= len(n.runes); start++ {
+		end := start + len(term.runes)
+
+		if !n.matchesAt(start, term) {
+			continue
+		}
+
+		if start > 0 && n.isWordAt(start-1) {
+			continue
+		}
+
+		if end < len(n.runes) && n.isWordAt(end) {
+			continue
+		}
+
+		matches = append(matches, [2]int{start, end})
+	}
+
+	return matches
+}
+
+// isWordAt reports whether the rune at i continues a word. Symbols read as
+// letters only count inside a match, so "idiot!" still ends after the "t".
+func (n normalized) isWordAt(i int) bool {
+	return isWordRune(n.runes[i]) && !n.symbols[i]
+}
+
+// matchesAt reports whether term occurs in n starting at the rune start.
+func (n normalized) matchesAt(start int, term normalized) bool {
+	for i, r := range term.runes {
+		if n.runes[start+i] != r || n.counts[start+i] < term.counts[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package contentfilter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const defaultCategory = "prohibited language"
+
+// Entry is a single term of a wordlist.
+type Entry struct {
+	Term     string
+	Action   Action
+	Category string
+}
+
+type rule struct {
+	term     normalized
+	action   Action
+	category string
+}
+
+// WordlistFilter matches whole words against a list of terms after folding
+// case, diacritics, unicode confusables and leet-speak, so "Ιd10t" matches
+// the term "idiot" but "idiotic" does not.
+type WordlistFilter struct {
+	rules []rule
+}
+
+func NewWordlistFilter(entries []Entry) *WordlistFilter {
+	f := &WordlistFilter{}
+
+	for _, entry := range entries {
+		term := normalizeTerm(entry.Term)
+		if len(term.runes) == 0 {
+			continue
+		}
+
+		category := entry.Category
+		if category == "" {
+			category = defaultCategory
+		}
+
+		f.rules = append(f.rules, rule{term: term, action: entry.Action, category: category})
+	}
+
+	return f
+}
+
+func (f *WordlistFilter) Check(text string) Result {
+	result := Result{Action: ActionAllow, Text: text}
+
+	if len(f.rules) == 0 || text == "" {
+		return result
+	}
+
+	n := normalize(text)
+
+	var masks [][2]int
+	categories := make(map[string]bool)
+
+	for _, rule := range f.rules {
+		matches := n.find(rule.term)
+		if len(matches) == 0 {
+			continue
+		}
+
+		if rule.action > result.Action {
+			result.Action = rule.action
+		}
+
+		categories[rule.category] = true
+
+		if rule.action == ActionMask {
+			for _, match := range matches {
+				masks = append(masks, [2]int{n.spans[match[0]][0], n.spans[match[1]-1][1]})
+			}
+		}
+	}
+
+	for category := range categories {
+		result.Reasons = append(result.Reasons, category)
+	}
+	sort.Strings(result.Reasons)
+
+	if result.Action == ActionMask {
+		result.Text = mask(text, masks)
+	}
+
+	return result
+}
+
+// mask replaces every rune inside the given byte ranges of text with '*'.
+func mask(text string, ranges [][2]int) string {
+	var b strings.Builder
+
+	for offset, r := range text {
+		masked := false
+		for _, rng := range ranges {
+			if offset >= rng[0] && offset < rng[1] {
+				masked = true
+				break
+			}
+		}
+
+		if masked {
+			b.WriteRune('*')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// ParseWordlist reads a wordlist with one entry per line in the form
+//
+//	term[,action[,category]]
+//
+// Entries without an action use defaultAction. Empty lines and lines
+// starting with # are ignored.
+func ParseWordlist(r io.Reader, defaultAction Action) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(text, ",", 3)
+
+		entry := Entry{
+			Term:   strings.TrimSpace(fields[0]),
+			Action: defaultAction,
+		}
+
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			action, err := ParseAction(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("wordlist line %d: %w", line, err)
+			}
+			entry.Action = action
+		}
+
+		if len(fields) > 2 {
+			entry.Category = strings.TrimSpace(fields[2])
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// LoadWordlistFilter builds a WordlistFilter from the wordlist file at path.
+// An empty path yields a filter that allows everything.
+func LoadWordlistFilter(path string, defaultAction Action) (*WordlistFilter, error) {
+	if path == "" {
+		return NewWordlistFilter(nil), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening wordlist: %w", err)
+	}
+	defer file.Close()
+
+	entries, err := ParseWordlist(file, defaultAction)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWordlistFilter(entries), nil
+}
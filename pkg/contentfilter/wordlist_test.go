@@ -0,0 +1,99 @@
+//go:build unit
+// +build unit
+
+package contentfilter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlistFilter(t *testing.T) {
+	filter := NewWordlistFilter([]Entry{
+		{Term: "idiot", Action: ActionMask, Category: "insult"},
+		{Term: "cheap pills", Action: ActionReject, Category: "spam"},
+		{Term: "boring", Action: ActionFlag},
+	})
+
+	t.Run("Wordlist filter: Clean text is allowed", func(t *testing.T) {
+		result := filter.Check("A wonderful and idiotically long book.")
+
+		assert.Equal(t, ActionAllow, result.Action)
+		assert.Equal(t, "A wonderful and idiotically long book.", result.Text)
+		assert.Empty(t, result.Reasons)
+	})
+
+	t.Run("Wordlist filter: Leet-speak and confusables are matched and masked", func(t *testing.T) {
+		result := filter.Check("What an 1d10t! And what an ιdιοt.")
+
+		assert.Equal(t, ActionMask, result.Action)
+		assert.Equal(t, "What an *****! And what an *****.", result.Text)
+		assert.Equal(t, []string{"insult"}, result.Reasons)
+	})
+
+	t.Run("Wordlist filter: Repeated letters, accents and invisible characters are folded", func(t *testing.T) {
+		result := filter.Check("IDIIIÖ​T")
+
+		assert.Equal(t, ActionMask, result.Action)
+		assert.Equal(t, strings.Repeat("*", 8), result.Text)
+	})
+
+	t.Run("Wordlist filter: Doubled letters in terms are not collapsed", func(t *testing.T) {
+		filter := NewWordlistFilter([]Entry{
+			{Term: "ass", Action: ActionMask},
+			{Term: "boob", Action: ActionReject},
+		})
+
+		result := filter.Check("As good as the first one, but Bob liked it less.")
+
+		assert.Equal(t, ActionAllow, result.Action)
+		assert.Equal(t, "As good as the first one, but Bob liked it less.", result.Text)
+
+		result = filter.Check("What an aaass.")
+
+		assert.Equal(t, ActionMask, result.Action)
+		assert.Equal(t, "What an *****.", result.Text)
+
+		assert.Equal(t, ActionReject, filter.Check("b00ooob").Action)
+	})
+
+	t.Run("Wordlist filter: Most severe action wins", func(t *testing.T) {
+		assert.Equal(t, ActionFlag, filter.Check("so b0ring").Action)
+
+		result := filter.Check("Boring, buy CHEAP \n p1lls")
+
+		assert.Equal(t, ActionReject, result.Action)
+		assert.Equal(t, []string{"prohibited language", "spam"}, result.Reasons)
+	})
+}
+
+func TestParseWordlist(t *testing.T) {
+
+	t.Run("Parse wordlist: Actions and categories", func(t *testing.T) {
+		wordlist := `
+# insults
+idiot,mask,insult
+cheap pills,,spam
+boring
+`
+
+		entries, err := ParseWordlist(strings.NewReader(wordlist), ActionReject)
+
+		require.NoError(t, err)
+		assert.Equal(t, []Entry{
+			{Term: "idiot", Action: ActionMask, Category: "insult"},
+			{Term: "cheap pills", Action: ActionReject, Category: "spam"},
+			{Term: "boring", Action: ActionReject},
+		}, entries)
+	})
+
+	t.Run("Parse wordlist: Unknown action", func(t *testing.T) {
+		_, err := ParseWordlist(strings.NewReader("idiot,shout"), ActionReject)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 1")
+	})
+}
@@ -0,0 +1,189 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Claims is implemented by token payloads so their time based claims can be
+// checked when a token is parsed.
+type Claims interface {
+	Valid(now time.Time) error
+}
+
+// RegisteredClaims holds the registered claim names of RFC 7519 that the API
+// uses. Times are in seconds since the Unix epoch.
+type RegisteredClaims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	ID        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+func (c RegisteredClaims) Valid(now time.Time) error {
+	if c.ExpiresAt != 0 && now.Unix() >= c.ExpiresAt {
+		return ErrExpiredToken
+	}
+
+	if c.NotBefore != 0 && now.Unix() < c.NotBefore {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Sign encodes claims as a JWT signed with HMAC-SHA256.
+func Sign(claims Claims, secret []byte) (string, error) {
+	signingInput, err := encode(header{Algorithm: "HS256", Type: "JWT"}, claims)
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encoding.EncodeToString(sign(signingInput, secret)), nil
+}
+
+// SignRS256 encodes claims as a JWT signed with RSASSA-PKCS1-v1_5 SHA-256.
+// keyID is put in the header so verifiers can pick the matching public key.
+func SignRS256(claims Claims, key *rsa.PrivateKey, keyID string) (string, error) {
+	signingInput, err := encode(header{Algorithm: "RS256", Type: "JWT", KeyID: keyID}, claims)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
+
+// Parse verifies the HMAC-SHA256 signature of token, decodes its payload into
+// claims and checks that the claims are valid now. Tokens using any other
+// algorithm are rejected.
+func Parse(token string, secret []byte, claims Claims) error {
+	parts, _, err := split(token, "HS256")
+	if err != nil {
+		return err
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	if !hmac.Equal(signature, sign(parts[0]+"."+parts[1], secret)) {
+		return ErrInvalidToken
+	}
+
+	return decodeClaims(parts[1], claims)
+}
+
+// KeyFunc returns the RSA public key with the given key ID.
+type KeyFunc func(keyID string) (*rsa.PublicKey, error)
+
+// ParseRS256 is like Parse for tokens signed with RSASSA-PKCS1-v1_5 SHA-256.
+// The public key is looked up with the key ID in the token header; errors
+// returned by key are passed on unchanged.
+func ParseRS256(token string, key KeyFunc, claims Claims) error {
+	parts, h, err := split(token, "RS256")
+	if err != nil {
+		return err
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	publicKey, err := key(h.KeyID)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) != nil {
+		return ErrInvalidToken
+	}
+
+	return decodeClaims(parts[1], claims)
+}
+
+func encode(h header, claims Claims) (string, error) {
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(headerJSON) + "." + encoding.EncodeToString(claimsJSON), nil
+}
+
+// split splits token into its three segments and decodes its header, which
+// must name algorithm.
+func split(token, algorithm string) ([]string, *header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil || h.Algorithm != algorithm {
+		return nil, nil, ErrInvalidToken
+	}
+
+	return parts, &h, nil
+}
+
+func decodeClaims(segment string, claims Claims) error {
+	if err := decodeSegment(segment, claims); err != nil {
+		return ErrInvalidToken
+	}
+
+	return claims.Valid(time.Now())
+}
+
+func sign(signingInput string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v any) error {
+	js, err := encoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js, v)
+}
@@ -0,0 +1,128 @@
+//go:build unit
+// +build unit
+
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndParse(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	t.Run("Parse: Valid token", func(t *testing.T) {
+		token, err := Sign(RegisteredClaims{Subject: "user", ExpiresAt: time.Now().Add(time.Minute).Unix()}, secret)
+		require.NoError(t, err)
+
+		var claims RegisteredClaims
+		require.NoError(t, Parse(token, secret, &claims))
+		assert.Equal(t, "user", claims.Subject)
+	})
+
+	t.Run("Parse: Expired token", func(t *testing.T) {
+		token, err := Sign(RegisteredClaims{Subject: "user", ExpiresAt: time.Now().Add(-time.Minute).Unix()}, secret)
+		require.NoError(t, err)
+
+		var claims RegisteredClaims
+		assert.ErrorIs(t, Parse(token, secret, &claims), ErrExpiredToken)
+	})
+
+	t.Run("Parse: Wrong secret", func(t *testing.T) {
+		token, err := Sign(RegisteredClaims{Subject: "user"}, secret)
+		require.NoError(t, err)
+
+		var claims RegisteredClaims
+		assert.ErrorIs(t, Parse(token, []byte("another secret"), &claims), ErrInvalidToken)
+	})
+
+	t.Run("Parse: Tampered payload", func(t *testing.T) {
+		token, err := Sign(RegisteredClaims{Subject: "user"}, secret)
+		require.NoError(t, err)
+
+		forged, err := Sign(RegisteredClaims{Subject: "admin"}, secret)
+		require.NoError(t, err)
+
+		parts := strings.Split(token, ".")
+		parts[1] = strings.Split(forged, ".")[1]
+
+		var claims RegisteredClaims
+		assert.ErrorIs(t, Parse(strings.Join(parts, "."), secret, &claims), ErrInvalidToken)
+	})
+
+	t.Run("Parse: Unsigned token", func(t *testing.T) {
+		token, err := Sign(RegisteredClaims{Subject: "user"}, secret)
+		require.NoError(t, err)
+
+		parts := strings.Split(token, ".")
+		parts[0] = encoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+		parts[2] = ""
+
+		var claims RegisteredClaims
+		assert.ErrorIs(t, Parse(strings.Join(parts, "."), secret, &claims), ErrInvalidToken)
+	})
+
+	t.Run("Parse: Malformed token", func(t *testing.T) {
+		var claims RegisteredClaims
+		assert.ErrorIs(t, Parse("not a token", secret, &claims), ErrInvalidToken)
+	})
+}
+
+func TestSignAndParseRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys := func(keyID string) (*rsa.PublicKey, error) {
+		switch keyID {
+		case "key-1":
+			return &key.PublicKey, nil
+		case "key-2":
+			return &other.PublicKey, nil
+		default:
+			return nil, errors.New("unknown key")
+		}
+	}
+
+	t.Run("ParseRS256: Valid token", func(t *testing.T) {
+		token, err := SignRS256(RegisteredClaims{Subject: "user", ExpiresAt: time.Now().Add(time.Minute).Unix()}, key, "key-1")
+		require.NoError(t, err)
+
+		var claims RegisteredClaims
+		require.NoError(t, ParseRS256(token, keys, &claims))
+		assert.Equal(t, "user", claims.Subject)
+	})
+
+	t.Run("ParseRS256: Signed with another key", func(t *testing.T) {
+		token, err := SignRS256(RegisteredClaims{Subject: "user"}, key, "key-2")
+		require.NoError(t, err)
+
+		var claims RegisteredClaims
+		assert.ErrorIs(t, ParseRS256(token, keys, &claims), ErrInvalidToken)
+	})
+
+	t.Run("ParseRS256: Unknown key", func(t *testing.T) {
+		token, err := SignRS256(RegisteredClaims{Subject: "user"}, key, "key-3")
+		require.NoError(t, err)
+
+		var claims RegisteredClaims
+		assert.EqualError(t, ParseRS256(token, keys, &claims), "unknown key")
+	})
+
+	t.Run("ParseRS256: HMAC token", func(t *testing.T) {
+		token, err := Sign(RegisteredClaims{Subject: "user"}, []byte("0123456789abcdef0123456789abcdef"))
+		require.NoError(t, err)
+
+		var claims RegisteredClaims
+		assert.ErrorIs(t, ParseRS256(token, keys, &claims), ErrInvalidToken)
+	})
+}
@@ -0,0 +1,27 @@
+package mailer
+
+import "log/slog"
+
+type asyncMailer struct {
+	mailer Mailer
+}
+
+// NewAsyncMailer returns a mailer that sends emails in the background, so
+// requests do not wait for the mail server and take as long whether or not
+// an email is sent. Failures are logged.
+func NewAsyncMailer(mailer Mailer) Mailer {
+	return &asyncMailer{
+		mailer: mailer,
+	}
+}
+
+func (m *asyncMailer) Send(msg *Message) error {
+	go func() {
+		err := m.mailer.Send(msg)
+		if err != nil {
+			slog.Error("Could not send email", "subject", msg.Subject, "error", err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,120 @@
+//go:build unit
+// +build unit
+
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var templates = fstest.MapFS{
+	"welcome.tmpl": {Data: []byte(`
+{{define "subject"}}Welcome, {{.Name}}{{end}}
+{{define "text"}}
+Hi {{.Name}},
+
+Your code is {{.Code}}.
+{{end}}
+{{define "html"}}
+<p>Hi {{.Name}},</p>
+<p>Your code is <strong>{{.Code}}</strong>.</p>
+{{end}}
+`)},
+}
+
+func TestNewMessage(t *testing.T) {
+	msg, err := NewMessage(templates, "welcome.tmpl", "jane.doe@example.com", map[string]string{"Name": "Jane <b>Doe</b>", "Code": "1234"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "jane.doe@example.com", msg.To)
+	assert.Equal(t, "Welcome, Jane <b>Doe</b>", msg.Subject)
+	assert.Equal(t, "Hi Jane <b>Doe</b>,\n\nYour code is 1234.", msg.Text)
+	assert.Contains(t, msg.HTML, "<p>Hi Jane &lt;b&gt;Doe&lt;/b&gt;,</p>")
+
+	_, err = NewMessage(templates, "missing.tmpl", "jane.doe@example.com", nil)
+	assert.Error(t, err)
+}
+
+// parse parses an encoded message and returns its headers and the bodies of
+// its parts by content type.
+func parse(t *testing.T, data []byte) (mail.Header, map[string]string) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	bodies := make(map[string]string)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+	}
+
+	return msg.Header, bodies
+}
+
+func TestWriterMailer(t *testing.T) {
+	var buf bytes.Buffer
+
+	mailer := NewWriterMailer(&buf, "Book Review API <no-reply@example.com>")
+
+	err := mailer.Send(&Message{To: "jane.doe@example.com", Subject: "Réinitialiser", Text: "Plain", HTML: "<p>HTML</p>"})
+	require.NoError(t, err)
+
+	header, bodies := parse(t, buf.Bytes())
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Réinitialiser", subject)
+	assert.Equal(t, "jane.doe@example.com", header.Get("To"))
+	assert.Equal(t, "Plain", bodies["text/plain"])
+	assert.Equal(t, "<p>HTML</p>", bodies["text/html"])
+
+	err = mailer.Send(&Message{To: "jane.doe@example.com\r\nBcc: john.doe@example.com", Subject: "Hi"})
+	assert.Error(t, err)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	mailer, err := NewFileMailer(dir, "no-reply@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, mailer.Send(&Message{To: "jane.doe@example.com", Subject: "First", Text: "1"}))
+	require.NoError(t, mailer.Send(&Message{To: "jane.doe@example.com", Subject: "Second", Text: "2"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+
+	header, bodies := parse(t, data)
+	assert.Equal(t, "Second", header.Get("Subject"))
+	assert.Equal(t, "2", bodies["text/plain"])
+}
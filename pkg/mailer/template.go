@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	"text/template"
+)
+
+// NewMessage renders the template file name in fsys into an email to the
+// given recipient. The file must define the templates "subject", "text" and
+// "html". The html template is rendered with html/template, so data is
+// escaped there.
+func NewMessage(fsys fs.FS, name, to string, data any) (*Message, error) {
+	text, err := template.ParseFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.ParseFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := new(bytes.Buffer)
+	if err := text.ExecuteTemplate(subject, "subject", data); err != nil {
+		return nil, err
+	}
+
+	plain := new(bytes.Buffer)
+	if err := text.ExecuteTemplate(plain, "text", data); err != nil {
+		return nil, err
+	}
+
+	body := new(bytes.Buffer)
+	if err := html.ExecuteTemplate(body, "html", data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(plain.String()),
+		HTML:    strings.TrimSpace(body.String()),
+	}, nil
+}
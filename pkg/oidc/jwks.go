@@ -0,0 +1,129 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// keySetTTL is how long fetched keys are used before they are fetched
+	// again.
+	keySetTTL = time.Hour
+	// keySetMinRefresh limits how often an unknown key ID can trigger a
+	// fetch, so tokens with made up key IDs cannot flood the provider.
+	keySetMinRefresh = 10 * time.Second
+)
+
+// KeySet caches the signing keys published at a JWKS URL. Keys are fetched
+// again once they are older than an hour, or earlier when a token names a key
+// that is not in the cache, which is how providers roll over to a new key.
+//
+// A KeySet is safe for concurrent use.
+type KeySet struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func NewKeySet(url string, client *http.Client) *KeySet {
+	return &KeySet{
+		url:    url,
+		client: client,
+	}
+}
+
+// Key returns the RSA public key with the given key ID.
+func (s *KeySet) Key(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[keyID]
+	age := time.Since(s.fetchedAt)
+
+	switch {
+	case ok && age < keySetTTL:
+		return key, nil
+	case !ok && age < keySetMinRefresh:
+		return nil, ErrUnknownKey
+	}
+
+	err := s.fetch(ctx)
+	if err != nil {
+		// Keep using a cached key while the provider is unreachable.
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, ok = s.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+func (s *KeySet) fetch(ctx context.Context) error {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	err := getJSON(ctx, s.client, s.url, &set)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		key, err := rsaPublicKey(jwk)
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.KeyID] = key
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+
+	return nil
+}
+
+func rsaPublicKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,220 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/pkg/jwt"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	ErrUnknownKey     = errors.New("unknown signing key")
+)
+
+// Config describes the client registration at an OpenID Provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes are requested in addition to openid. Defaults to email and
+	// profile.
+	Scopes []string
+}
+
+// metadata is the subset of the provider's discovery document that the
+// authorization code flow needs.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Provider that users can log in with using the
+// authorization code flow with PKCE.
+type Provider struct {
+	config   Config
+	metadata metadata
+	keys     *KeySet
+	client   *http.Client
+}
+
+// NewProvider fetches the discovery document of the issuer in config.
+func NewProvider(ctx context.Context, config Config) (*Provider, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	issuer := strings.TrimSuffix(config.Issuer, "/")
+
+	var m metadata
+	err := getJSON(ctx, client, issuer+"/.well-known/openid-configuration", &m)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+
+	if m.Issuer != issuer {
+		return nil, fmt.Errorf("oidc: discovery: issuer %q does not match %q", m.Issuer, issuer)
+	}
+
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery: missing endpoints")
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"email", "profile"}
+	}
+
+	return &Provider{
+		config:   config,
+		metadata: m,
+		keys:     NewKeySet(m.JWKSURI, client),
+		client:   client,
+	}, nil
+}
+
+// Issuer returns the issuer identifier of the provider.
+func (p *Provider) Issuer() string {
+	return p.metadata.Issuer
+}
+
+// AuthCodeURL returns the URL to send the user to for logging in. The code
+// challenge is derived from a verifier with Challenge.
+func (p *Provider) AuthCodeURL(state, nonce, challenge string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.config.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return p.metadata.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// TokenError is an error response of the token endpoint.
+type TokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description == "" {
+		return "oidc: token endpoint: " + e.Code
+	}
+	return "oidc: token endpoint: " + e.Code + ": " + e.Description
+}
+
+// Exchange redeems an authorization code and returns the verified claims of
+// the ID token issued for it. verifier and nonce must be the ones the
+// authorization request was made with.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token endpoint: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		tokenErr := &TokenError{Code: res.Status}
+		_ = json.NewDecoder(res.Body).Decode(tokenErr)
+		return nil, tokenErr
+	}
+
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token endpoint: %w", err)
+	}
+
+	if body.IDToken == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return p.Verify(ctx, body.IDToken, nonce)
+}
+
+// Verify checks the signature of an ID token against the provider's keys and
+// validates its issuer, audience, expiry and nonce.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	var token IDToken
+
+	err := jwt.ParseRS256(rawIDToken, func(keyID string) (*rsa.PublicKey, error) {
+		return p.keys.Key(ctx, keyID)
+	}, &token)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, jwt.ErrInvalidToken), errors.Is(err, jwt.ErrExpiredToken), errors.Is(err, ErrUnknownKey):
+			return nil, ErrInvalidIDToken
+		default:
+			return nil, err
+		}
+	}
+
+	switch {
+	case token.Issuer != p.metadata.Issuer,
+		token.Subject == "",
+		token.ExpiresAt == 0,
+		!token.Audience.Contains(p.config.ClientID),
+		len(token.Audience) > 1 && token.AuthorizedParty != p.config.ClientID,
+		token.Nonce != nonce:
+		return nil, ErrInvalidIDToken
+	}
+
+	return &token, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %s", url, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,197 @@
+//go:build unit
+// +build unit
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jakottelaar/gobookreviewapp/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "https://books.example.com/v1/api/auth/oidc/callback"
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Server) {
+	idp := oidctest.NewServer("book-api", "client-secret")
+	t.Cleanup(idp.Close)
+
+	provider, err := NewProvider(context.Background(), Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     "book-api",
+		ClientSecret: "client-secret",
+		RedirectURL:  redirectURL,
+	})
+	require.NoError(t, err)
+
+	return provider, idp
+}
+
+// authorize follows an authorization URL to the provider and returns the
+// query of the redirect back to the client.
+func authorize(t *testing.T, authURL string) url.Values {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+
+	return location.Query()
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	provider, idp := newTestProvider(t)
+
+	idp.SetUser(oidctest.User{Subject: "42", Email: "jane.doe@example.com", EmailVerified: true, Name: "Jane Doe"})
+
+	t.Run("Exchange: Successfully exchange a code", func(t *testing.T) {
+		verifier, err := NewVerifier()
+		require.NoError(t, err)
+
+		query := authorize(t, provider.AuthCodeURL("state", "nonce", Challenge(verifier)))
+		assert.Equal(t, "state", query.Get("state"))
+
+		token, err := provider.Exchange(context.Background(), query.Get("code"), verifier, "nonce")
+		require.NoError(t, err)
+
+		assert.Equal(t, "42", token.Subject)
+		assert.Equal(t, "jane.doe@example.com", token.Email)
+		assert.True(t, token.EmailVerified)
+	})
+
+	t.Run("Exchange: Wrong code verifier", func(t *testing.T) {
+		verifier, err := NewVerifier()
+		require.NoError(t, err)
+
+		query := authorize(t, provider.AuthCodeURL("state", "nonce", Challenge(verifier)))
+
+		other, err := NewVerifier()
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(context.Background(), query.Get("code"), other, "nonce")
+
+		var tokenErr *TokenError
+		require.ErrorAs(t, err, &tokenErr)
+		assert.Equal(t, "invalid_grant", tokenErr.Code)
+	})
+
+	t.Run("Exchange: Code is single use", func(t *testing.T) {
+		verifier, err := NewVerifier()
+		require.NoError(t, err)
+
+		query := authorize(t, provider.AuthCodeURL("state", "nonce", Challenge(verifier)))
+
+		_, err = provider.Exchange(context.Background(), query.Get("code"), verifier, "nonce")
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(context.Background(), query.Get("code"), verifier, "nonce")
+		assert.Error(t, err)
+	})
+
+	t.Run("Exchange: Wrong nonce", func(t *testing.T) {
+		verifier, err := NewVerifier()
+		require.NoError(t, err)
+
+		query := authorize(t, provider.AuthCodeURL("state", "nonce", Challenge(verifier)))
+
+		_, err = provider.Exchange(context.Background(), query.Get("code"), verifier, "another nonce")
+		assert.ErrorIs(t, err, ErrInvalidIDToken)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	provider, idp := newTestProvider(t)
+
+	user := oidctest.User{Subject: "42", Email: "jane.doe@example.com"}
+
+	sign := func(modify func(c *oidctest.Claims)) string {
+		claims := idp.IDToken(user, "nonce")
+		modify(&claims)
+
+		token, err := idp.Sign(claims)
+		require.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *oidctest.Claims)
+	}{
+		{"Expired", func(c *oidctest.Claims) { c.ExpiresAt = time.Now().Add(-time.Minute).Unix() }},
+		{"No expiry", func(c *oidctest.Claims) { c.ExpiresAt = 0 }},
+		{"Other issuer", func(c *oidctest.Claims) { c.Issuer = "https://evil.example.com" }},
+		{"Other audience", func(c *oidctest.Claims) { c.Audience = "another-client" }},
+		{"No subject", func(c *oidctest.Claims) { c.Subject = "" }},
+	}
+
+	t.Run("Verify: Valid token", func(t *testing.T) {
+		token, err := provider.Verify(context.Background(), sign(func(c *oidctest.Claims) {}), "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, "42", token.Subject)
+	})
+
+	for _, tt := range tests {
+		t.Run("Verify: "+tt.name, func(t *testing.T) {
+			_, err := provider.Verify(context.Background(), sign(tt.modify), "nonce")
+			assert.ErrorIs(t, err, ErrInvalidIDToken)
+		})
+	}
+
+	t.Run("Verify: Signing key was rotated", func(t *testing.T) {
+		idp.RotateKey()
+
+		token := sign(func(c *oidctest.Claims) {})
+
+		// Unknown key IDs only trigger a fetch after keySetMinRefresh.
+		_, err := provider.Verify(context.Background(), token, "nonce")
+		assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+		provider.keys.fetchedAt = provider.keys.fetchedAt.Add(-keySetMinRefresh)
+
+		_, err = provider.Verify(context.Background(), token, "nonce")
+		assert.NoError(t, err)
+	})
+}
+
+func TestNewProvider(t *testing.T) {
+	idp := oidctest.NewServer("book-api", "client-secret")
+	defer idp.Close()
+
+	_, err := NewProvider(context.Background(), Config{Issuer: idp.Issuer() + "/tenant", ClientID: "book-api"})
+	assert.Error(t, err)
+}
+
+func TestAudience(t *testing.T) {
+	var token IDToken
+
+	require.NoError(t, json.Unmarshal([]byte(`{"aud": "book-api"}`), &token))
+	assert.Equal(t, Audience{"book-api"}, token.Audience)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"aud": ["book-api", "other"]}`), &token))
+	assert.True(t, token.Audience.Contains("other"))
+	assert.False(t, token.Audience.Contains("book"))
+}
+
+func TestChallenge(t *testing.T) {
+	// Base64url encoded, unpadded SHA-256 of "abc".
+	assert.Equal(t, "ungWv48Bz-pBQUDeXa4iI7ADYaOWF3qctBD_YfIAFa0", Challenge("abc"))
+
+	verifier, err := NewVerifier()
+	require.NoError(t, err)
+	assert.Len(t, verifier, 43)
+}
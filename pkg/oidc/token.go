@@ -0,0 +1,47 @@
+package oidc
+
+import (
+	"encoding/json"
+
+	"github.com/jakottelaar/gobookreviewapp/pkg/jwt"
+)
+
+// IDToken holds the claims of an ID token that are used to identify a user.
+type IDToken struct {
+	jwt.RegisteredClaims
+	Audience        Audience `json:"aud"`
+	AuthorizedParty string   `json:"azp,omitempty"`
+	Nonce           string   `json:"nonce,omitempty"`
+	Email           string   `json:"email,omitempty"`
+	EmailVerified   bool     `json:"email_verified,omitempty"`
+	Name            string   `json:"name,omitempty"`
+}
+
+// Audience is the aud claim, which providers send either as a single string
+// or as an array of strings.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+	return nil
+}
+
+func (a Audience) Contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+//go:build unit
+// +build unit
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("Allow: Limits each key separately", func(t *testing.T) {
+		limiter := New(2, time.Hour)
+
+		assert.True(t, limiter.Allow("a"))
+		assert.True(t, limiter.Allow("a"))
+		assert.False(t, limiter.Allow("a"))
+		assert.True(t, limiter.Allow("b"))
+	})
+
+	t.Run("Allow: Starts over in a new window", func(t *testing.T) {
+		limiter := New(1, 10*time.Millisecond)
+
+		assert.True(t, limiter.Allow("a"))
+		assert.False(t, limiter.Allow("a"))
+
+		time.Sleep(20 * time.Millisecond)
+
+		assert.True(t, limiter.Allow("a"))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	limiter := New(1, time.Minute)
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/v1/api/auth/password-reset", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1:1234").Code)
+
+	limited := request("192.0.2.1:5678")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "60", limited.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.2:1234").Code)
+}
@@ -0,0 +1,197 @@
+package tfidf
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Match is a document that is similar to the queried document.
+type Match struct {
+	ID    string
+	Score float64
+}
+
+// Index is an in-memory TF-IDF index that ranks documents by the cosine
+// similarity of their term vectors. Documents can be added, replaced and
+// removed at any time; weights are derived from the current document
+// frequencies when the index is queried, so nothing has to be rebuilt.
+//
+// An Index is safe for concurrent use.
+type Index struct {
+	mu   sync.RWMutex
+	docs map[string]map[string]int
+	df   map[string]int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs: make(map[string]map[string]int),
+		df:   make(map[string]int),
+	}
+}
+
+// Add indexes text under id, replacing any text previously indexed under it.
+func (i *Index) Add(id, text string) {
+	counts := make(map[string]int)
+	for _, term := range Tokenize(text) {
+		counts[term]++
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+
+	i.docs[id] = counts
+	for term := range counts {
+		i.df[term]++
+	}
+}
+
+func (i *Index) Remove(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+}
+
+func (i *Index) remove(id string) {
+	counts, ok := i.docs[id]
+	if !ok {
+		return
+	}
+
+	for term := range counts {
+		i.df[term]--
+		if i.df[term] == 0 {
+			delete(i.df, term)
+		}
+	}
+
+	delete(i.docs, id)
+}
+
+func (i *Index) Contains(id string) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	_, ok := i.docs[id]
+	return ok
+}
+
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return len(i.docs)
+}
+
+// Similar returns up to limit documents that share terms with the document
+// indexed under id, most similar first. It returns nil if id is not indexed.
+func (i *Index) Similar(id string, limit int) []Match {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	counts, ok := i.docs[id]
+	if !ok {
+		return nil
+	}
+
+	query := i.vector(counts)
+	queryNorm := norm(query)
+	if queryNorm == 0 {
+		return nil
+	}
+
+	var matches []Match
+
+	for docID, docCounts := range i.docs {
+		if docID == id {
+			continue
+		}
+
+		doc := i.vector(docCounts)
+
+		var dot float64
+		for term, weight := range query {
+			dot += weight * doc[term]
+		}
+
+		if dot == 0 {
+			continue
+		}
+
+		matches = append(matches, Match{ID: docID, Score: dot / (queryNorm * norm(doc))})
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].ID < matches[b].ID
+	})
+
+	if limit >= 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches
+}
+
+// vector weighs term counts with a sublinear term frequency and a smoothed
+// inverse document frequency.
+func (i *Index) vector(counts map[string]int) map[string]float64 {
+	n := float64(len(i.docs))
+	vector := make(map[string]float64, len(counts))
+
+	for term, count := range counts {
+		tf := 1 + math.Log(float64(count))
+		idf := 1 + math.Log((1+n)/(1+float64(i.df[term])))
+		vector[term] = tf * idf
+	}
+
+	return vector
+}
+
+func norm(vector map[string]float64) float64 {
+	var sum float64
+	for _, weight := range vector {
+		sum += weight * weight
+	}
+	return math.Sqrt(sum)
+}
+
+// Tokenize splits text into lowercase terms on anything that is not a letter
+// or digit, dropping single characters and common English stop words.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := fields[:0]
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || stopWords[field] {
+			continue
+		}
+		terms = append(terms, field)
+	}
+
+	return terms
+}
+
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "an": true, "and": true,
+	"any": true, "are": true, "as": true, "at": true, "be": true, "been": true,
+	"but": true, "by": true, "can": true, "do": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "he": true, "her": true, "his": true,
+	"how": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "more": true, "no": true, "not": true, "of": true, "on": true,
+	"one": true, "or": true, "our": true, "she": true, "so": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "they": true,
+	"this": true, "to": true, "up": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "which": true, "who": true, "will": true,
+	"with": true, "you": true, "your": true,
+}
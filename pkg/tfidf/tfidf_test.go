@@ -0,0 +1,64 @@
+//go:build unit
+// +build unit
+
+package tfidf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"great", "gatsby", "jazz", "age", "story", "1925"}, Tokenize("The Great Gatsby: a Jazz-Age story, 1925"))
+	assert.Empty(t, Tokenize("A of the"))
+}
+
+func TestIndexSimilar(t *testing.T) {
+	index := NewIndex()
+	index.Add("gatsby", "The Great Gatsby. A portrait of the Jazz Age, wealth and excess on Long Island. F. Scott Fitzgerald")
+	index.Add("tender", "Tender Is the Night. Wealth and excess of Americans on the French Riviera in the Jazz Age. F. Scott Fitzgerald")
+	index.Add("moby", "Moby-Dick. The voyage of the whaling ship Pequod and its captain. Herman Melville")
+	index.Add("dune", "Dune. A desert planet, spice and politics. Frank Herbert")
+
+	t.Run("Similar: Ranks documents sharing rare terms first", func(t *testing.T) {
+		matches := index.Similar("gatsby", 10)
+
+		require.Len(t, matches, 1)
+		assert.Equal(t, "tender", matches[0].ID)
+		assert.Greater(t, matches[0].Score, 0.0)
+		assert.LessOrEqual(t, matches[0].Score, 1.0)
+	})
+
+	t.Run("Similar: Respects the limit", func(t *testing.T) {
+		index.Add("beautiful", "The Beautiful and Damned. Jazz Age New York. F. Scott Fitzgerald")
+
+		assert.Len(t, index.Similar("gatsby", 1), 1)
+		assert.Len(t, index.Similar("gatsby", 10), 2)
+	})
+
+	t.Run("Similar: Unknown document", func(t *testing.T) {
+		assert.Nil(t, index.Similar("unknown", 10))
+	})
+
+	t.Run("Add: Replaces the indexed text", func(t *testing.T) {
+		index.Add("dune", "Dune Messiah. Jazz Age Long Island. F. Scott Fitzgerald")
+		assert.Equal(t, "dune", index.Similar("gatsby", 1)[0].ID)
+
+		index.Add("dune", "Dune. A desert planet, spice and politics. Frank Herbert")
+		assert.Empty(t, index.Similar("dune", 10))
+	})
+
+	t.Run("Remove: Drops the document and its terms", func(t *testing.T) {
+		index.Remove("beautiful")
+		index.Remove("beautiful")
+
+		assert.False(t, index.Contains("beautiful"))
+		assert.Equal(t, 4, index.Len())
+
+		matches := index.Similar("gatsby", 10)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "tender", matches[0].ID)
+	})
+}
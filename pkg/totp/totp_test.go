@@ -0,0 +1,82 @@
+//go:build unit
+// +build unit
+
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 secret of the test vectors in RFC 6238, appendix B.
+var rfcSecret = []byte("12345678901234567890")
+
+func TestRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		step := Step(time.Unix(tt.unix, 0))
+		assert.Equal(t, tt.code, hotp(rfcSecret, uint64(step), 8), tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+
+	t.Run("Validate: Current code", func(t *testing.T) {
+		step, ok := Validate(secret, Code(secret, Step(now)), now)
+
+		assert.True(t, ok)
+		assert.Equal(t, Step(now), step)
+	})
+
+	t.Run("Validate: Codes one period off are accepted", func(t *testing.T) {
+		step, ok := Validate(secret, Code(secret, Step(now)-1), now)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now)-1, step)
+
+		_, ok = Validate(secret, Code(secret, Step(now)+1), now)
+		assert.True(t, ok)
+	})
+
+	t.Run("Validate: Old codes are rejected", func(t *testing.T) {
+		_, ok := Validate(secret, Code(secret, Step(now)-2), now)
+		assert.False(t, ok)
+	})
+
+	t.Run("Validate: Malformed codes are rejected", func(t *testing.T) {
+		_, ok := Validate(secret, "", now)
+		assert.False(t, ok)
+
+		_, ok = Validate(secret, Code(secret, Step(now))+"0", now)
+		assert.False(t, ok)
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("Book Reviews", "jane.doe@example.com", rfcSecret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Book Reviews:jane.doe@example.com", uri.Path)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", uri.Query().Get("secret"))
+	assert.Equal(t, "Book Reviews", uri.Query().Get("issuer"))
+}
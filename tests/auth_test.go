@@ -0,0 +1,729 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
+	"github.com/jakottelaar/gobookreviewapp/pkg/database"
+	"github.com/jakottelaar/gobookreviewapp/pkg/oidc/oidctest"
+	"github.com/jakottelaar/gobookreviewapp/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authClient sends requests as a freshly registered admin.
+var authClient *http.Client
+
+type bearerTransport struct {
+	token string
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// registerUser registers a user with a unique email address and returns its
+// email address and password.
+func registerUser() (string, string, error) {
+	email := fmt.Sprintf("user-%s@example.com", uuid.NewString())
+	password := "correct-Horse-battery-5taple"
+
+	reqBody := fmt.Sprintf(`{"email": %q, "name": "Jane Doe", "password": %q}`, email, password)
+
+	res, err := http.Post(testServer.URL+"/v1/api/users", "application/json", strings.NewReader(reqBody))
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return "", "", fmt.Errorf("registering user: unexpected status %d", res.StatusCode)
+	}
+
+	return email, password, nil
+}
+
+func login(email, password string) (map[string]interface{}, error) {
+	reqBody := fmt.Sprintf(`{"email": %q, "password": %q}`, email, password)
+
+	res, err := http.Post(testServer.URL+"/v1/api/auth/login", "application/json", strings.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("logging in: unexpected status %d", res.StatusCode)
+	}
+
+	var response map[string]map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response["tokens"], nil
+}
+
+// newAuthClient registers a user with the given role and returns a client
+// that sends requests with its access token. Roles can only be changed by an
+// admin, so the role is set directly in the database.
+func newAuthClient(role string) (*http.Client, error) {
+	email, password, err := registerUser()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = database.GetDB().Exec("UPDATE users SET role = $1 WHERE LOWER(email) = LOWER($2)", role, email)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := login(email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Transport: &bearerTransport{token: tokens["access_token"].(string)}}
+
+	if user.RequiresTwoFactor(role) {
+		_, _, err = enableTwoFactor(client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+// enableTwoFactor enables two-factor authentication for the user of client
+// and returns its TOTP secret and recovery codes. The code used to enable it
+// is of the current time step, so logins should use the next one.
+func enableTwoFactor(client *http.Client) ([]byte, []string, error) {
+	res, err := client.Post(testServer.URL+"/v1/api/me/two-factor", "application/json", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("setting up two-factor authentication: unexpected status %d", res.StatusCode)
+	}
+
+	var enrollment map[string]map[string]string
+	err = json.NewDecoder(res.Body).Decode(&enrollment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment["two_factor"]["secret"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reqBody := fmt.Sprintf(`{"code": %q}`, totp.Code(secret, totp.Step(time.Now())))
+
+	res, err = client.Post(testServer.URL+"/v1/api/me/two-factor/confirm", "application/json", strings.NewReader(reqBody))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("enabling two-factor authentication: unexpected status %d", res.StatusCode)
+	}
+
+	var codes map[string][]string
+	err = json.NewDecoder(res.Body).Decode(&codes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return secret, codes["recovery_codes"], nil
+}
+
+func TestMutatingBookRoutesRequireAuthentication(t *testing.T) {
+	reqBody := `{"title": "Book Title", "author": "Book Author", "published_year": 2020, "isbn": "9780743273565"}`
+
+	res, err := http.Post(baseBooksEndpointUrl, "application/json", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Equal(t, "Bearer", res.Header.Get("WWW-Authenticate"))
+
+	req, err := http.NewRequest("POST", baseBooksEndpointUrl, strings.NewReader(reqBody))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer not-a-token")
+
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestBookRoutesRequirePermission(t *testing.T) {
+	readerClient, err := newAuthClient(user.RoleReader)
+	require.NoError(t, err)
+
+	reqBody := `{"title": "Book Title", "author": "Book Author", "published_year": 2020, "isbn": "9780743273565"}`
+
+	res, err := readerClient.Post(baseBooksEndpointUrl, "application/json", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res, err = authClient.Post(baseBooksEndpointUrl, "application/json", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var response map[string]map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	require.NoError(t, err)
+
+	bookID := response["book"]["id"].(string)
+
+	res, err = readerClient.Post(baseBooksEndpointUrl+bookID+"/suggestions", "application/json", strings.NewReader(`{"changes": {"page_count": 180}}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	res, err = http.Get(testServer.URL + "/v1/api/suggestions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, err = readerClient.Get(testServer.URL + "/v1/api/suggestions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res, err = readerClient.Get(testServer.URL + "/v1/api/me/suggestions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var own map[string][]map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&own)
+	require.NoError(t, err)
+
+	require.Len(t, own["suggestions"], 1)
+	assert.Equal(t, bookID, own["suggestions"][0]["book_id"])
+
+	req, err := http.NewRequest("DELETE", baseBooksEndpointUrl+bookID, nil)
+	require.NoError(t, err)
+
+	res, err = readerClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	refresh := func(token string) *http.Response {
+		res, err := http.Post(testServer.URL+"/v1/api/auth/refresh", "application/json", strings.NewReader(fmt.Sprintf(`{"refresh_token": %q}`, token)))
+		require.NoError(t, err)
+		return res
+	}
+
+	first := tokens["refresh_token"].(string)
+
+	res := refresh(first)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var response map[string]map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	require.NoError(t, err)
+
+	second := response["tokens"]["refresh_token"].(string)
+	assert.NotEqual(t, first, second)
+
+	// Reusing the rotated token revokes the whole family, including the
+	// token it was rotated into.
+	res = refresh(first)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res = refresh(second)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestAPIKeyRequests(t *testing.T) {
+	res, err := authClient.Post(testServer.URL+"/v1/api/api-keys", "application/json", strings.NewReader(`{"name": "read only", "scopes": ["books:read"]}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var response map[string]map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	require.NoError(t, err)
+
+	keyID := response["api_key"]["id"].(string)
+	keyClient := &http.Client{Transport: &bearerTransport{token: response["api_key"]["key"].(string)}}
+
+	reqBody := `{"title": "Book Title", "author": "Book Author", "published_year": 2020, "isbn": "9780743273565"}`
+
+	res, err = keyClient.Post(baseBooksEndpointUrl, "application/json", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res, err = keyClient.Get(testServer.URL + "/v1/api/suggestions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	req, err := http.NewRequest("DELETE", testServer.URL+"/v1/api/api-keys/"+keyID, nil)
+	require.NoError(t, err)
+
+	res, err = authClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = keyClient.Get(testServer.URL + "/v1/api/suggestions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+// oidcLogin logs in at the identity provider as u and returns the response of
+// the callback.
+func oidcLogin(t *testing.T, u oidctest.User) *http.Response {
+	identityProvider.SetUser(u)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	client := &http.Client{Jar: jar}
+
+	res, err := client.Get(testServer.URL + "/v1/api/auth/oidc/login")
+	require.NoError(t, err)
+
+	return res
+}
+
+func TestOIDCLogin(t *testing.T) {
+	t.Run("OIDC login: Provisions a user on the first login", func(t *testing.T) {
+		u := oidctest.User{Subject: uuid.NewString(), Email: fmt.Sprintf("sso-%s@example.com", uuid.NewString()), EmailVerified: true, Name: "Jane Doe"}
+
+		res := oidcLogin(t, u)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response map[string]map[string]interface{}
+		err := json.NewDecoder(res.Body).Decode(&response)
+		require.NoError(t, err)
+
+		client := &http.Client{Transport: &bearerTransport{token: response["tokens"]["access_token"].(string)}}
+
+		res, err = client.Get(testServer.URL + "/v1/api/api-keys")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		// Users provisioned by the identity provider cannot log in with a
+		// password.
+		_, err = login(u.Email, "correct-Horse-battery-5taple")
+		assert.Error(t, err)
+	})
+
+	t.Run("OIDC login: Links a verified email address to the existing user", func(t *testing.T) {
+		email, _, err := registerUser()
+		require.NoError(t, err)
+
+		_, err = database.GetDB().Exec("UPDATE users SET email_verified_at = NOW() WHERE LOWER(email) = LOWER($1)", email)
+		require.NoError(t, err)
+
+		subject := uuid.NewString()
+
+		res := oidcLogin(t, oidctest.User{Subject: subject, Email: email, EmailVerified: true})
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var linked bool
+		err = database.GetDB().QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM user_identities i JOIN users u ON u.id = i.user_id
+				WHERE i.subject = $1 AND LOWER(u.email) = LOWER($2)
+			)`, subject, email).Scan(&linked)
+		require.NoError(t, err)
+		assert.True(t, linked)
+	})
+
+	t.Run("OIDC login: Unverified email address is already registered", func(t *testing.T) {
+		email, _, err := registerUser()
+		require.NoError(t, err)
+
+		res := oidcLogin(t, oidctest.User{Subject: uuid.NewString(), Email: email})
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+	})
+
+	t.Run("OIDC login: Existing user has not verified the email address", func(t *testing.T) {
+		email, _, err := registerUser()
+		require.NoError(t, err)
+
+		res := oidcLogin(t, oidctest.User{Subject: uuid.NewString(), Email: email, EmailVerified: true})
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		var verified bool
+		err = database.GetDB().QueryRow("SELECT email_verified_at IS NOT NULL FROM users WHERE LOWER(email) = LOWER($1)", email).Scan(&verified)
+		require.NoError(t, err)
+		assert.False(t, verified)
+	})
+
+	t.Run("OIDC login: Logged in user links an account", func(t *testing.T) {
+		email, password, err := registerUser()
+		require.NoError(t, err)
+
+		tokens, err := login(email, password)
+		require.NoError(t, err)
+
+		jar, err := cookiejar.New(nil)
+		require.NoError(t, err)
+
+		client := &http.Client{Jar: jar, Transport: &bearerTransport{token: tokens["access_token"].(string)}}
+
+		res, err := client.Post(testServer.URL+"/v1/api/auth/oidc/link", "application/json", nil)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response map[string]string
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+
+		subject := uuid.NewString()
+		identityProvider.SetUser(oidctest.User{Subject: subject, Email: fmt.Sprintf("sso-%s@example.org", uuid.NewString())})
+
+		res, err = client.Get(response["authorization_url"])
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var linked bool
+		err = database.GetDB().QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM user_identities i JOIN users u ON u.id = i.user_id
+				WHERE i.subject = $1 AND LOWER(u.email) = LOWER($2)
+			)`, subject, email).Scan(&linked)
+		require.NoError(t, err)
+		assert.True(t, linked)
+	})
+
+	t.Run("OIDC login: Callback without a started login", func(t *testing.T) {
+		res, err := http.Get(testServer.URL + "/v1/api/auth/oidc/callback?code=code&state=state")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestSessions(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	loginWithUserAgent := func(userAgent string) map[string]interface{} {
+		req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/api/auth/login", strings.NewReader(fmt.Sprintf(`{"email": %q, "password": %q}`, email, password)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response map[string]map[string]interface{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+
+		return response["tokens"]
+	}
+
+	laptop := loginWithUserAgent("Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0")
+	phone := loginWithUserAgent("Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36")
+
+	laptopClient := &http.Client{Transport: &bearerTransport{token: laptop["access_token"].(string)}}
+	phoneClient := &http.Client{Transport: &bearerTransport{token: phone["access_token"].(string)}}
+
+	res, err := laptopClient.Get(testServer.URL + "/v1/api/me/sessions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var response struct {
+		Sessions []struct {
+			ID      string `json:"id"`
+			Device  string `json:"device"`
+			Current bool   `json:"current"`
+		} `json:"sessions"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+	require.Len(t, response.Sessions, 2)
+
+	// The phone logged in last, so its session was used most recently
+	assert.Equal(t, "Chrome on Android", response.Sessions[0].Device)
+	assert.False(t, response.Sessions[0].Current)
+	assert.Equal(t, "Firefox on Linux", response.Sessions[1].Device)
+	assert.True(t, response.Sessions[1].Current)
+
+	req, err := http.NewRequest(http.MethodDelete, testServer.URL+"/v1/api/me/sessions/"+response.Sessions[0].ID, nil)
+	require.NoError(t, err)
+
+	res, err = laptopClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// The access token of the revoked session is rejected before it expires
+	res, err = phoneClient.Get(testServer.URL + "/v1/api/me/sessions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, err = http.Post(testServer.URL+"/v1/api/auth/refresh", "application/json", strings.NewReader(fmt.Sprintf(`{"refresh_token": %q}`, phone["refresh_token"])))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// Sessions of other users cannot be revoked
+	res, err = authClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, err = laptopClient.Get(testServer.URL + "/v1/api/me/sessions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: &bearerTransport{token: tokens["access_token"].(string)}}
+
+	secret, recoveryCodes, err := enableTwoFactor(client)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, 10)
+
+	startLogin := func() string {
+		res, err := http.Post(testServer.URL+"/v1/api/auth/login", "application/json", strings.NewReader(fmt.Sprintf(`{"email": %q, "password": %q}`, email, password)))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+		var response map[string]map[string]interface{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.NotContains(t, response, "tokens")
+
+		return response["two_factor_challenge"]["challenge_token"].(string)
+	}
+
+	completeLogin := func(challenge, code string) *http.Response {
+		res, err := http.Post(testServer.URL+"/v1/api/auth/login/two-factor", "application/json", strings.NewReader(fmt.Sprintf(`{"challenge_token": %q, "code": %q}`, challenge, code)))
+		require.NoError(t, err)
+		return res
+	}
+
+	// The password alone no longer logs in
+	_, err = login(email, password)
+	require.Error(t, err)
+
+	code := totp.Code(secret, totp.Step(time.Now())+1)
+
+	res := completeLogin(startLogin(), code)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Codes work only once
+	res = completeLogin(startLogin(), code)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res = completeLogin(startLogin(), recoveryCodes[0])
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = completeLogin(startLogin(), recoveryCodes[0])
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// Challenges are not access tokens
+	challengeClient := &http.Client{Transport: &bearerTransport{token: startLogin()}}
+
+	res, err = challengeClient.Get(testServer.URL + "/v1/api/me/sessions")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: &bearerTransport{token: tokens["access_token"].(string)}}
+
+	secret, _, err := enableTwoFactor(client)
+	require.NoError(t, err)
+
+	// Wrong codes count towards the lockout wherever they are entered
+	for i := 0; i < 5; i++ {
+		res, err := client.Post(testServer.URL+"/v1/api/me/two-factor/recovery-codes", "application/json", strings.NewReader(`{"code": "000000"}`))
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	}
+
+	res, err := http.Post(testServer.URL+"/v1/api/auth/login", "application/json", strings.NewReader(fmt.Sprintf(`{"email": %q, "password": %q}`, email, password)))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	var response map[string]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+
+	challenge := response["two_factor_challenge"]["challenge_token"].(string)
+
+	// Even the right code is refused while locked out
+	res, err = http.Post(testServer.URL+"/v1/api/auth/login/two-factor", "application/json", strings.NewReader(fmt.Sprintf(`{"challenge_token": %q, "code": %q}`, challenge, totp.Code(secret, totp.Step(time.Now())+1))))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+}
+
+func TestTwoFactorLockoutConcurrentGuesses(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: &bearerTransport{token: tokens["access_token"].(string)}}
+
+	_, _, err = enableTwoFactor(client)
+	require.NoError(t, err)
+
+	const guesses = 20
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, guesses)
+
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := client.Post(testServer.URL+"/v1/api/me/two-factor/recovery-codes", "application/json", strings.NewReader(`{"code": "000000"}`))
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			res.Body.Close()
+
+			statuses <- res.StatusCode
+		}()
+	}
+
+	wg.Wait()
+	close(statuses)
+
+	counts := make(map[int]int)
+	for status := range statuses {
+		counts[status]++
+	}
+
+	// Only the attempts up to the lockout get a code checked
+	assert.Equal(t, map[int]int{http.StatusBadRequest: 5, http.StatusTooManyRequests: guesses - 5}, counts)
+
+	var attempts int
+	err = database.GetDB().QueryRow("SELECT totp_attempts FROM users WHERE LOWER(email) = LOWER($1)", email).Scan(&attempts)
+	require.NoError(t, err)
+	assert.Equal(t, 5, attempts)
+}
+
+func TestTwoFactorRequiredForModerators(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	_, err = database.GetDB().Exec("UPDATE users SET role = $1 WHERE LOWER(email) = LOWER($2)", user.RoleModerator, email)
+	require.NoError(t, err)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: &bearerTransport{token: tokens["access_token"].(string)}}
+
+	res, err := client.Post(testServer.URL+"/v1/api/suggestions/"+uuid.NewString()+"/approve", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	secret, _, err := enableTwoFactor(client)
+	require.NoError(t, err)
+
+	res, err = client.Post(testServer.URL+"/v1/api/suggestions/"+uuid.NewString()+"/approve", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// Moderators cannot turn it off again
+	req, err := http.NewRequest(http.MethodDelete, testServer.URL+"/v1/api/me/two-factor", strings.NewReader(fmt.Sprintf(`{"code": %q}`, totp.Code(secret, totp.Step(time.Now())+1))))
+	require.NoError(t, err)
+
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
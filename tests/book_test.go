@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/database"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -26,7 +28,7 @@ func TestCreateBookRequest(t *testing.T) {
 	req, err := http.NewRequest("POST", baseBooksEndpointUrl, strings.NewReader(reqBody))
 	require.NoError(t, err)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := authClient.Do(req)
 	require.NoError(t, err)
 	defer res.Body.Close()
 
@@ -86,7 +88,7 @@ func TestUpdateBookById(t *testing.T) {
 		t.Fatalf("Could not create request: %v", err)
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := authClient.Do(req)
 	require.NoError(t, err)
 	defer res.Body.Close()
 
@@ -113,7 +115,7 @@ func TestDeleteBookById(t *testing.T) {
 		t.Fatalf("Could not create request: %v", err)
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := authClient.Do(req)
 	require.NoError(t, err)
 	defer res.Body.Close()
 
@@ -128,3 +130,146 @@ func TestDeleteBookById(t *testing.T) {
 	assert.Equal(t, "Successfully deleted book", message)
 
 }
+
+func TestBatchRequest(t *testing.T) {
+	// The title is unique, so the book can be looked up after the batch.
+	title := "Batch Book " + uuid.NewString()
+
+	reqBody := `{"operations": [
+		{"op": "create", "book": {"title": "` + title + `", "author": "Batch Author", "published_year": 2020, "isbn": "9780743273565"}},
+		{"op": "update", "id": "00000000-0000-0000-0000-000000000000", "book": {"title": "Missing", "author": "Missing", "published_year": 2020, "isbn": "9780743273565"}}
+	]}`
+
+	req, err := http.NewRequest("POST", testServer.URL+"/v1/api/batch", strings.NewReader(reqBody))
+	require.NoError(t, err)
+
+	res, err := authClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	var response map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(1), response["operation"])
+
+	var count int
+	err = database.GetDB().QueryRow("SELECT COUNT(*) FROM books WHERE title = $1", title).Scan(&count)
+	require.NoError(t, err)
+
+	assert.Zero(t, count, "the book created by the failed batch should be rolled back")
+}
+
+func TestMergeBookRequest(t *testing.T) {
+	createBook := func(title string) string {
+		reqBody := `{"title": "` + title + `", "author": "Book Author", "published_year": 2020, "isbn": "9780743273565"}`
+
+		res, err := authClient.Post(baseBooksEndpointUrl, "application/json", strings.NewReader(reqBody))
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var response map[string]map[string]interface{}
+		err = json.NewDecoder(res.Body).Decode(&response)
+		require.NoError(t, err)
+
+		return response["book"]["id"].(string)
+	}
+
+	targetId := createBook("Merge Target")
+	sourceId := createBook("Merge Source")
+
+	req, err := http.NewRequest(http.MethodPut, baseBooksEndpointUrl+sourceId+"/translations/de", strings.NewReader(`{"title": "Zusammenführen"}`))
+	require.NoError(t, err)
+
+	res, err := authClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = authClient.Post(baseBooksEndpointUrl+sourceId+"/suggestions", "application/json", strings.NewReader(`{"changes": {"subtitle": "A Merged Book"}}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var submitted map[string]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&submitted))
+
+	suggestionId := submitted["suggestion"]["id"].(string)
+
+	res, err = authClient.Post(baseBooksEndpointUrl+targetId+"/merge", "application/json", strings.NewReader(`{"source_id": "`+sourceId+`"}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err = client.Get(baseBooksEndpointUrl + sourceId)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusPermanentRedirect, res.StatusCode)
+	assert.Equal(t, "/v1/api/books/"+targetId, res.Header.Get("Location"))
+
+	res, err = http.Get(baseBooksEndpointUrl + targetId + "/translations")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var translations map[string][]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&translations))
+	require.Len(t, translations["translations"], 1)
+	assert.Equal(t, "Zusammenführen", translations["translations"][0]["title"])
+
+	res, err = client.Get(baseBooksEndpointUrl + sourceId + "/translations")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusPermanentRedirect, res.StatusCode)
+	assert.Equal(t, "/v1/api/books/"+targetId+"/translations", res.Header.Get("Location"))
+
+	res, err = authClient.Get(testServer.URL + "/v1/api/suggestions/" + suggestionId)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var suggestion map[string]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&suggestion))
+	assert.Equal(t, targetId, suggestion["suggestion"]["book_id"])
+	assert.Equal(t, "pending", suggestion["suggestion"]["status"])
+
+	t.Run("Opposite merges at the same time", func(t *testing.T) {
+		a := createBook("Merge A")
+		b := createBook("Merge B")
+
+		merge := func(sourceId, targetId string, status chan<- int) {
+			res, err := authClient.Post(baseBooksEndpointUrl+targetId+"/merge", "application/json", strings.NewReader(`{"source_id": "`+sourceId+`"}`))
+			if err != nil {
+				status <- 0
+				return
+			}
+			res.Body.Close()
+			status <- res.StatusCode
+		}
+
+		status := make(chan int, 2)
+		go merge(a, b, status)
+		go merge(b, a, status)
+
+		codes := []int{<-status, <-status}
+		assert.ElementsMatch(t, []int{http.StatusOK, http.StatusNotFound}, codes)
+
+		var deleted int
+		err := database.GetDB().QueryRow("SELECT COUNT(*) FROM books WHERE id IN ($1, $2) AND deleted_at IS NOT NULL", a, b).Scan(&deleted)
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+	})
+}
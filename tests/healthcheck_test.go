@@ -8,21 +8,52 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/jakottelaar/gobookreviewapp/api"
 	"github.com/jakottelaar/gobookreviewapp/config"
+	"github.com/jakottelaar/gobookreviewapp/internal/user"
 	"github.com/jakottelaar/gobookreviewapp/pkg/database"
+	"github.com/jakottelaar/gobookreviewapp/pkg/oidc/oidctest"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
 	testServer           *httptest.Server
+	identityProvider     *oidctest.Server
 	baseBooksEndpointUrl string
+	// mailDir is where the emails sent by the API are written to.
+	mailDir string
 )
 
 func TestMain(m *testing.M) {
 
+	// The test server is started before the routes exist, so its URL can be
+	// registered as redirect URL at the identity provider.
+	testServer = httptest.NewUnstartedServer(nil)
+	testServer.Start()
+	defer testServer.Close()
+
+	identityProvider = oidctest.NewServer("book-api", "client-secret")
+	defer identityProvider.Close()
+
+	os.Setenv("OIDC_ISSUER", identityProvider.Issuer())
+	os.Setenv("OIDC_CLIENT_ID", identityProvider.ClientID)
+	os.Setenv("OIDC_CLIENT_SECRET", identityProvider.ClientSecret)
+	os.Setenv("OIDC_REDIRECT_URL", testServer.URL+"/v1/api/auth/oidc/callback")
+
+	var err error
+
+	mailDir, err = os.MkdirTemp("", "mail")
+	if err != nil {
+		log.Fatalf("Could not create mail directory: %v", err)
+	}
+	defer os.RemoveAll(mailDir)
+
+	os.Setenv("MAILER", "file")
+	os.Setenv("MAILER_DIR", mailDir)
+
 	cfg, err := config.Load()
 
 	if err != nil {
@@ -35,12 +66,20 @@ func TestMain(m *testing.M) {
 	}
 	defer database.Close()
 
-	routes := api.SetupRoutes()
+	routes, _, err := api.SetupRoutes(cfg)
+	if err != nil {
+		log.Fatalf("Could not setup routes: %v", err)
+	}
 
-	testServer = httptest.NewServer(routes)
+	testServer.Config.Handler = routes
 
 	baseBooksEndpointUrl = testServer.URL + "/v1/api/books/"
 
+	authClient, err = newAuthClient(user.RoleAdmin)
+	if err != nil {
+		log.Fatalf("Could not authenticate: %v", err)
+	}
+
 	m.Run()
 
 }
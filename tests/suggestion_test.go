@@ -0,0 +1,61 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproveSuggestionKeepsLaterEdits(t *testing.T) {
+	res, err := authClient.Post(baseBooksEndpointUrl, "application/json", strings.NewReader(`{"title": "The Grate Gatsby", "author": "F. Scott Fitzgerald", "published_year": 1925, "isbn": "9780743273565"}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var created map[string]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+
+	bookID := created["book"]["id"].(string)
+
+	res, err = authClient.Post(baseBooksEndpointUrl+bookID+"/suggestions", "application/json", strings.NewReader(`{"changes": {"title": "The Great Gatsby"}}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var submitted map[string]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&submitted))
+
+	suggestionID := submitted["suggestion"]["id"].(string)
+
+	// The book is edited while the suggestion waits for review
+	req, err := http.NewRequest(http.MethodPut, baseBooksEndpointUrl+bookID, strings.NewReader(`{"title": "The Grate Gatsby", "author": "F. Scott Fitzgerald", "published_year": 1925, "isbn": "9780743273565", "page_count": 208}`))
+	require.NoError(t, err)
+
+	res, err = authClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = authClient.Post(testServer.URL+"/v1/api/suggestions/"+suggestionID+"/approve", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(baseBooksEndpointUrl + bookID)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var book map[string]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&book))
+
+	assert.Equal(t, "The Great Gatsby", book["book"]["title"])
+	assert.Equal(t, float64(208), book["book"]["page_count"])
+}
@@ -0,0 +1,375 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakottelaar/gobookreviewapp/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterRequest(t *testing.T) {
+	email := fmt.Sprintf("user-%s@example.com", uuid.NewString())
+	password := "correct-Horse-battery-5taple"
+
+	reqBody := fmt.Sprintf(`{"email": %q, "name": "Jane Doe", "password": %q}`, email, password)
+
+	res, err := http.Post(testServer.URL+"/v1/api/users", "application/json", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var response map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	require.NoError(t, err)
+
+	user, ok := response["user"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, email, user["email"])
+	assert.NotContains(t, user, "password_hash")
+
+	// Email addresses are unique regardless of case
+	duplicateBody := fmt.Sprintf(`{"email": %q, "name": "Jane Doe", "password": %q}`, strings.ToUpper(email), password)
+
+	res, err = http.Post(testServer.URL+"/v1/api/users", "application/json", strings.NewReader(duplicateBody))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	_, err = login(email, password)
+	assert.NoError(t, err)
+
+	loginBody := fmt.Sprintf(`{"email": %q, "password": "wrong-Password-1"}`, email)
+
+	res, err = http.Post(testServer.URL+"/v1/api/auth/login", "application/json", strings.NewReader(loginBody))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+// readMailToken waits for an email to the given address with a link to path
+// and returns the token in the link. Emails are sent in the background, so it
+// polls the mail directory for a while.
+func readMailToken(t *testing.T, to, path string) string {
+	t.Helper()
+
+	link := regexp.MustCompile(regexp.QuoteMeta(path) + `\?token=([A-Za-z0-9_-]+)`)
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		names, err := filepath.Glob(filepath.Join(mailDir, "*.eml"))
+		require.NoError(t, err)
+
+		// Newest first, so the latest token is returned.
+		sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+		for _, name := range names {
+			text, ok := readMailText(t, name, to)
+			if !ok {
+				continue
+			}
+
+			if match := link.FindStringSubmatch(text); match != nil {
+				return match[1]
+			}
+		}
+	}
+
+	t.Fatalf("no email with a %s link was sent to %s", path, to)
+	return ""
+}
+
+// readMailText returns the plain text part of an email if it was sent to the
+// given address.
+func readMailText(t *testing.T, name, to string) (string, bool) {
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	require.NoError(t, err)
+
+	if !strings.EqualFold(msg.Header.Get("To"), to) {
+		return "", false
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+
+	part, err := multipart.NewReader(msg.Body, params["boundary"]).NextPart()
+	require.NoError(t, err)
+
+	text, err := io.ReadAll(part)
+	require.NoError(t, err)
+
+	return string(text), true
+}
+
+func TestEmailVerification(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	verify := func(token string) *http.Response {
+		res, err := http.Post(testServer.URL+"/v1/api/auth/verify-email", "application/json", strings.NewReader(fmt.Sprintf(`{"token": %q}`, token)))
+		require.NoError(t, err)
+		return res
+	}
+
+	token := readMailToken(t, email, "/verify-email")
+
+	res := verify(token)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Tokens can only be used once
+	res = verify(token)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/api/auth/verify-email/resend", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+tokens["access_token"].(string))
+
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestPasswordReset(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	requestReset := func(email string) *http.Response {
+		res, err := http.Post(testServer.URL+"/v1/api/auth/password-reset", "application/json", strings.NewReader(fmt.Sprintf(`{"email": %q}`, email)))
+		require.NoError(t, err)
+		return res
+	}
+
+	// Unknown addresses get the same response
+	res := requestReset("nobody-" + uuid.NewString() + "@example.com")
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	res = requestReset(email)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	token := readMailToken(t, email, "/reset-password")
+	newPassword := "new-Horse-battery-5taple"
+
+	res, err = http.Post(testServer.URL+"/v1/api/auth/password-reset/confirm", "application/json", strings.NewReader(fmt.Sprintf(`{"token": %q, "password": %q}`, token, newPassword)))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	_, err = login(email, password)
+	assert.Error(t, err)
+
+	_, err = login(email, newPassword)
+	assert.NoError(t, err)
+
+	// Sessions started with the old password are logged out
+	res, err = http.Post(testServer.URL+"/v1/api/auth/refresh", "application/json", strings.NewReader(fmt.Sprintf(`{"refresh_token": %q}`, tokens["refresh_token"])))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestProfiles(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: &bearerTransport{token: tokens["access_token"].(string)}}
+
+	res, err := authClient.Post(baseBooksEndpointUrl, "application/json", strings.NewReader(`{"title": "Book Title", "author": "Book Author", "published_year": 2020, "isbn": "9780743273565"}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var book map[string]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&book))
+
+	bookID := book["book"]["id"].(string)
+
+	updateProfile := func(reqBody string) map[string]interface{} {
+		req, err := http.NewRequest(http.MethodPut, testServer.URL+"/v1/api/me/profile", strings.NewReader(reqBody))
+		require.NoError(t, err)
+
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response map[string]map[string]interface{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+
+		return response["profile"]
+	}
+
+	getPublicProfile := func(id string) map[string]interface{} {
+		res, err := http.Get(testServer.URL + "/v1/api/users/" + id)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response map[string]map[string]interface{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+
+		return response["profile"]
+	}
+
+	own := updateProfile(`{"display_name": "Jane", "bio": "Reads mostly classics.", "avatar_url": "https://example.com/avatars/jane.png"}`)
+	id := own["id"].(string)
+
+	// There are no endpoints for reviews and shelves yet
+	_, err = database.GetDB().Exec("INSERT INTO reviews (id, book_id, user_id, rating) VALUES ($1, $2, $3, 4)", uuid.NewString(), bookID, id)
+	require.NoError(t, err)
+
+	_, err = database.GetDB().Exec("INSERT INTO shelf_entries (user_id, book_id, shelf) VALUES ($1, $2, 'read')", id, bookID)
+	require.NoError(t, err)
+
+	public := getPublicProfile(id)
+	assert.Equal(t, "Jane", public["display_name"])
+	assert.Equal(t, "https://example.com/avatars/jane.png", public["avatar_url"])
+	assert.Equal(t, float64(1), public["stats"].(map[string]interface{})["review_count"])
+	assert.Equal(t, float64(1), public["stats"].(map[string]interface{})["books_read"])
+	assert.NotContains(t, public, "email")
+
+	own = updateProfile(`{"display_name": "Jane", "hide_activity": true}`)
+	assert.Equal(t, float64(1), own["stats"].(map[string]interface{})["review_count"])
+
+	public = getPublicProfile(id)
+	assert.NotContains(t, public, "stats")
+	assert.NotContains(t, public, "avatar_url")
+
+	res, err = http.Get(testServer.URL + "/v1/api/users/" + uuid.NewString())
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestDataExportAndDeletion(t *testing.T) {
+	email, password, err := registerUser()
+	require.NoError(t, err)
+
+	tokens, err := login(email, password)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: &bearerTransport{token: tokens["access_token"].(string)}}
+
+	res, err := client.Post(testServer.URL+"/v1/api/me/export", "application/json", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	location := res.Header.Get("Location")
+	require.NotEmpty(t, location)
+
+	var export map[string]interface{}
+
+	require.Eventually(t, func() bool {
+		res, err := client.Get(testServer.URL + location)
+		if err != nil {
+			return false
+		}
+		defer res.Body.Close()
+
+		var response map[string]map[string]interface{}
+		if json.NewDecoder(res.Body).Decode(&response) != nil {
+			return false
+		}
+
+		export = response["export"]
+		return export["status"] == "ready"
+	}, 5*time.Second, 100*time.Millisecond)
+
+	res, err = client.Get(testServer.URL + location + "/download")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/zip", res.Header.Get("Content-Type"))
+
+	archive, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	assert.ElementsMatch(t, []string{"account.json", "identities.json", "sessions.json", "api_keys.json", "tokens.json", "suggestions.json", "reviews.json", "README.txt"}, names)
+
+	res, err = authClient.Get(testServer.URL + location)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, err = client.Get(testServer.URL + "/v1/api/me/profile")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var profile map[string]map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&profile))
+	id := profile["profile"]["id"].(string)
+
+	res, err = client.Post(testServer.URL+"/v1/api/me/deletion", "application/json", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	res, err = client.Post(testServer.URL+"/v1/api/me/deletion", "application/json", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	res, err = http.Get(testServer.URL + "/v1/api/users/" + id)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	req, err := http.NewRequest(http.MethodDelete, testServer.URL+"/v1/api/me/deletion", nil)
+	require.NoError(t, err)
+
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(testServer.URL + "/v1/api/users/" + id)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}